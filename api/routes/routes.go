@@ -4,6 +4,7 @@ package routes
 import (
 	"star-go/internal/controllers"
 	"star-go/pkg/middleware"
+	"star-go/pkg/sms"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,9 @@ func SetupRoutes(router *gin.Engine) {
 	// 添加全局限流中间件 - 每分钟180个请求
 	router.Use(middleware.RateLimit(180, time.Minute))
 
+	// 公开的标准发现端点
+	setupWellKnownRoutes(router)
+
 	// API版本分组
 	apiGroup := router.Group("/api")
 	{
@@ -27,6 +31,22 @@ func SetupRoutes(router *gin.Engine) {
 
 		// 设置用户相关路由
 		setupUserRoutes(apiGroup)
+
+		// 设置角色管理路由
+		setupRoleRoutes(apiGroup)
+
+		// 设置短信相关路由
+		setupSMSRoutes(apiGroup)
+	}
+}
+
+// 设置标准发现端点路由
+func setupWellKnownRoutes(router *gin.Engine) {
+	authController := controllers.NewAuthController()
+	wellKnownGroup := router.Group("/.well-known")
+	{
+		// 供其他服务验证令牌签名的公钥集合
+		wellKnownGroup.GET("/jwks.json", authController.JWKS)
 	}
 }
 
@@ -34,25 +54,91 @@ func SetupRoutes(router *gin.Engine) {
 func setupAuthRoutes(apiGroup *gin.RouterGroup) {
 	// 创建认证控制器实例
 	authController := controllers.NewAuthController()
+	sessionController := controllers.NewSessionController()
+	passwordResetController := controllers.NewPasswordResetController()
+	phoneController := controllers.NewPhoneController()
+	captchaController := controllers.NewCaptchaController()
+	mfaController := controllers.NewMFAController()
+	emailVerifyController := controllers.NewEmailVerifyController()
+	oauthController := controllers.NewOAuthController()
+	apiKeyController := controllers.NewAPIKeyController()
 	// 公开路由组
 	publicGroup := apiGroup.Group("/auth")
 	{
-		// 用户注册
-		publicGroup.POST("/register", authController.Register)
-		// 用户登录
-		publicGroup.POST("/login", authController.Login)
+		// 获取图形验证码
+		publicGroup.GET("/captcha", captchaController.Generate)
+		// 用户注册 - 失败过多时需要图形验证码
+		publicGroup.POST("/register", middleware.CaptchaGuard(), authController.Register)
+		// 用户登录 - 失败过多时需要图形验证码
+		publicGroup.POST("/login", middleware.CaptchaGuard(), authController.Login)
+		// 两步验证登录 - 失败过多时需要图形验证码
+		publicGroup.POST("/login/mfa", middleware.CaptchaGuard(), authController.LoginMFA)
 		// 刷新令牌
 		publicGroup.POST("/refresh", authController.RefreshToken)
+		// 邮箱验证 - 邮件中的验证链接
+		publicGroup.GET("/verify-email", emailVerifyController.VerifyEmail)
+		// 邮箱验证 - 提交令牌或验证码
+		publicGroup.POST("/verify-email", middleware.CaptchaGuard(), emailVerifyController.VerifyEmail)
+		// 重发验证邮件 - 失败过多时需要图形验证码
+		publicGroup.POST("/verify-email/resend", middleware.CaptchaGuard(), emailVerifyController.Resend)
+		// 找回密码 - 发送验证码
+		publicGroup.POST("/password/reset/code", middleware.CaptchaGuard(), passwordResetController.SendCode)
+		// 找回密码 - 校验验证码获取重置凭证
+		publicGroup.POST("/password/reset/verify", passwordResetController.VerifyCode)
+		// 找回密码 - 设置新密码
+		publicGroup.POST("/password/reset", passwordResetController.ResetPassword)
+		// 第三方登录 - 可用的身份提供方
+		publicGroup.GET("/oauth/providers", oauthController.Providers)
+		// 第三方登录 - 跳转到身份提供方授权
+		publicGroup.GET("/oauth/:provider/login", oauthController.Login)
+		// 第三方登录 - 授权回调
+		publicGroup.GET("/oauth/:provider/callback", oauthController.Callback)
 	}
 
-	// 需要认证的路由组
+	// 需要认证的路由组 - 账户安全相关操作不接受API密钥
 	authGroup := apiGroup.Group("/auth")
-	authGroup.Use(middleware.JWTAuth())
+	authGroup.Use(middleware.JWTAuth(), middleware.SessionOnly())
 	{
 		// 获取当前用户信息
 		authGroup.GET("/user", authController.GetUserInfo)
 		// 修改密码
 		authGroup.POST("/change-password", authController.ChangePassword)
+		// 退出登录
+		authGroup.POST("/logout", authController.Logout)
+		// 退出所有设备
+		authGroup.POST("/logout-all", authController.LogoutAll)
+		// 登录设备列表
+		authGroup.GET("/sessions", sessionController.ListMySessions)
+		// 下线指定设备
+		authGroup.DELETE("/sessions/:id", sessionController.RevokeMySession)
+		// 换绑手机号 - 向原手机号发送验证码
+		authGroup.POST("/phone/old/code", phoneController.SendOldPhoneCode)
+		// 换绑手机号 - 校验原手机号
+		authGroup.POST("/phone/old/verify", phoneController.VerifyOldPhone)
+		// 换绑手机号 - 向新手机号发送验证码
+		authGroup.POST("/phone/new/code", phoneController.SendNewPhoneCode)
+		// 换绑手机号 - 校验新手机号并完成绑定
+		authGroup.PUT("/phone", phoneController.ChangePhone)
+		// 两步验证 - 获取身份验证器绑定信息
+		authGroup.POST("/mfa/totp/setup", mfaController.SetupTOTP)
+		// 两步验证 - 校验验证码并开启
+		authGroup.POST("/mfa/totp/enable", mfaController.EnableTOTP)
+		// 两步验证 - 关闭
+		authGroup.POST("/mfa/totp/disable", mfaController.DisableTOTP)
+		// 两步验证 - 重新生成恢复码
+		authGroup.POST("/mfa/recovery-codes", mfaController.RegenerateRecoveryCodes)
+		// 第三方账户 - 已关联列表
+		authGroup.GET("/oauth/identities", oauthController.ListIdentities)
+		// 第三方账户 - 获取绑定授权地址
+		authGroup.POST("/oauth/:provider/link", oauthController.Link)
+		// 第三方账户 - 解除关联
+		authGroup.DELETE("/oauth/:provider", oauthController.Unlink)
+		// API密钥 - 列表
+		authGroup.GET("/api-keys", apiKeyController.ListAPIKeys)
+		// API密钥 - 创建
+		authGroup.POST("/api-keys", apiKeyController.CreateAPIKey)
+		// API密钥 - 吊销
+		authGroup.DELETE("/api-keys/:id", apiKeyController.RevokeAPIKey)
 	}
 }
 
@@ -60,6 +146,7 @@ func setupAuthRoutes(apiGroup *gin.RouterGroup) {
 func setupUserRoutes(apiGroup *gin.RouterGroup) {
 	// 创建用户控制器实例
 	userController := controllers.NewUserController()
+	sessionController := controllers.NewSessionController()
 
 	// 基础用户路由组 - 仅需要认证
 	baseUserGroup := apiGroup.Group("/users")
@@ -88,9 +175,58 @@ func setupUserRoutes(apiGroup *gin.RouterGroup) {
 		// 管理员特殊操作 - 这里可以添加其他管理员特有的操作
 		// 例如，复用现有的删除用户操作
 		adminGroup.DELETE("/:id", userController.DeleteUser)
+		// 查看指定用户的登录设备
+		adminGroup.GET("/:id/sessions", sessionController.ListUserSessions)
+		// 下线指定用户的登录设备
+		adminGroup.DELETE("/:id/sessions/:sid", sessionController.RevokeUserSession)
+	}
+}
+
+// 设置角色管理路由
+func setupRoleRoutes(apiGroup *gin.RouterGroup) {
+	// 创建角色控制器实例
+	roleController := controllers.NewRoleController()
+
+	// 角色管理路由组 - 需要 role:manage 权限
+	roleGroup := apiGroup.Group("/admin/roles")
+	roleGroup.Use(middleware.JWTAuth())
+	roleGroup.Use(middleware.PermissionAuth("role:manage"))
+	{
+		// 角色列表
+		roleGroup.GET("", roleController.GetRoles)
+		// 角色详情
+		roleGroup.GET("/:id", roleController.GetRole)
+		// 创建角色
+		roleGroup.POST("", roleController.CreateRole)
+		// 更新角色
+		roleGroup.PUT("/:id", roleController.UpdateRole)
+		// 删除角色 - 内置角色和仍被使用的角色不能删除
+		roleGroup.DELETE("/:id", roleController.DeleteRole)
+		// 为角色添加权限
+		roleGroup.POST("/:id/permissions", roleController.AddPermission)
+		// 移除角色的权限
+		roleGroup.DELETE("/:id/permissions/:permission", roleController.RemovePermission)
 	}
 }
 
+// 设置短信相关路由
 func setupSMSRoutes(apiGroup *gin.RouterGroup) {
-	
+	// 创建短信控制器实例
+	smsController := controllers.NewSMSController()
+	// 公开路由组
+	smsGroup := apiGroup.Group("/auth")
+	{
+		// 发送验证码 - 失败过多时需要图形验证码
+		smsGroup.POST("/sms/send", middleware.CaptchaGuard(), smsController.SendCode)
+		// 校验验证码
+		smsGroup.POST("/sms/verify", smsController.VerifyCode)
+		// 手机号验证码登录 - 失败过多时需要图形验证码
+		smsGroup.POST("/login/sms", middleware.CaptchaGuard(), smsController.Login)
+	}
+
+	// 内存短信服务商提供收件箱，便于开发和自动化测试读取验证码
+	// 收件箱包含所有验证码，仅在非release模式下挂载，并且需要管理员权限
+	if _, ok := sms.GetInbox(); ok && gin.Mode() != gin.ReleaseMode {
+		apiGroup.GET("/dev/sms/inbox", middleware.JWTAuth(), middleware.PermissionAuth("system:config"), smsController.Inbox)
+	}
 }
@@ -0,0 +1,100 @@
+// Package controllers internal/controllers/api_key_controller.go
+package controllers
+
+import (
+	"star-go/internal/services"
+	"star-go/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyController API密钥控制器
+type APIKeyController struct {
+	apiKeyService services.IAPIKeyService
+}
+
+// NewAPIKeyController 创建API密钥控制器实例
+func NewAPIKeyController() *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: services.NewAPIKeyService(),
+	}
+}
+
+// CreateAPIKeyRequest 创建API密钥请求
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,max=100"` // 授权范围，必须是当前用户权限的子集
+	ExpiresAt *time.Time `json:"expires_at"`                                   // 过期时间（RFC 3339），为空表示永不过期
+}
+
+// ListAPIKeys 获取当前用户的API密钥列表
+func (c *APIKeyController) ListAPIKeys(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	keys, err := c.apiKeyService.List(userID.(uint64))
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.Success(ctx, gin.H{
+		"api_keys": keys,
+	})
+}
+
+// CreateAPIKey 创建API密钥，明文密钥只在本次响应中返回
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	key, rawKey, err := c.apiKeyService.Create(userID.(uint64), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "API密钥创建成功，请妥善保存，密钥不会再次显示", gin.H{
+		"api_key": key,
+		"key":     rawKey,
+	})
+}
+
+// RevokeAPIKey 吊销API密钥
+func (c *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, "无效的密钥ID", nil)
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.apiKeyService.Revoke(userID.(uint64), id); err != nil {
+		utils.FailWithMessage(ctx, utils.NOT_FOUND, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "API密钥已吊销", nil)
+}
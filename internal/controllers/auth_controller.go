@@ -2,8 +2,13 @@
 package controllers
 
 import (
+	"errors"
+	"math"
+	"net/http"
+	"star-go/internal/models"
 	"star-go/internal/services"
 	"star-go/pkg/utils"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,7 +28,7 @@ func NewAuthController() *AuthController {
 // RegisterRequest 注册请求参数
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
-	Password string `json:"password" binding:"required,min=6,max=20"`
+	Password string `json:"password" binding:"required,max=128"`
 	Email    string `json:"email" binding:"required,email"`
 	Nickname string `json:"nickname" binding:"required,min=2,max=50"`
 }
@@ -34,6 +39,12 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// LoginMFARequest 两步验证登录请求参数
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP验证码或恢复码
+}
+
 // RefreshTokenRequest 刷新令牌请求参数
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
@@ -42,7 +53,7 @@ type RefreshTokenRequest struct {
 // ChangePasswordRequest 修改密码请求参数
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6,max=20"`
+	NewPassword string `json:"new_password" binding:"required,max=128"`
 }
 
 // Register 用户注册
@@ -73,27 +84,64 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, user, err := c.authService.Login(req.Username, req.Password)
+	result, err := c.authService.Login(ctx, req.Username, req.Password, clientInfo(ctx))
 	if err != nil {
-		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		var lockedErr *services.LoginLockedError
+		switch {
+		case errors.As(err, &lockedErr):
+			retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			utils.FailWithMessage(ctx, utils.TOO_MANY_REQUESTS, lockedErr.Error(), gin.H{
+				"retry_after": retryAfter,
+			})
+		case errors.Is(err, services.ErrInvalidCredentials):
+			utils.FailWithMessage(ctx, utils.UNAUTHORIZED, err.Error(), nil)
+		case errors.Is(err, services.ErrEmailNotVerified):
+			utils.FailWithMessage(ctx, utils.FORBIDDEN, err.Error(), gin.H{
+				"email_unverified": true,
+			})
+		default:
+			utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		}
 		return
 	}
 
-	utils.Success(ctx, gin.H{
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-		"user": gin.H{
-			"id":       user.ID,
-			"username": user.Username,
-			"nickname": user.Nickname,
-			"email":    user.Email,
-			"role": gin.H{
-				"id":   user.Role.ID,
-				"name": user.Role.Name,
-				"code": user.Role.Code,
-			},
-		},
-	})
+	// 开启两步验证时返回挑战令牌，由客户端提交验证码完成登录
+	if result.MFAToken != "" {
+		utils.SuccessWithMessage(ctx, "请输入两步验证码", gin.H{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		})
+		return
+	}
+
+	utils.Success(ctx, loginResponse(result.AccessToken, result.RefreshToken, result.User))
+}
+
+// LoginMFA 两步验证登录
+func (c *AuthController) LoginMFA(ctx *gin.Context) {
+	var req LoginMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	accessToken, refreshToken, user, err := c.authService.LoginMFA(ctx, req.MFAToken, req.Code, clientInfo(ctx))
+	if err != nil {
+		var lockedErr *services.LoginLockedError
+		if errors.As(err, &lockedErr) {
+			retryAfter := int(math.Ceil(lockedErr.RetryAfter.Seconds()))
+			ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+			utils.FailWithMessage(ctx, utils.TOO_MANY_REQUESTS, lockedErr.Error(), gin.H{
+				"retry_after": retryAfter,
+			})
+			return
+		}
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, err.Error(), nil)
+		return
+	}
+
+	utils.Success(ctx, loginResponse(accessToken, refreshToken, user))
 }
 
 // RefreshToken 刷新令牌
@@ -104,14 +152,15 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, err := c.authService.RefreshToken(req.RefreshToken)
+	accessToken, refreshToken, err := c.authService.RefreshToken(ctx, req.RefreshToken, clientInfo(ctx))
 	if err != nil {
-		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, err.Error(), nil)
 		return
 	}
 
 	utils.Success(ctx, gin.H{
-		"access_token": accessToken,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
 	})
 }
 
@@ -151,11 +200,7 @@ func (c *AuthController) GetUserInfo(ctx *gin.Context) {
 			"username": user.Username,
 			"nickname": user.Nickname,
 			"email":    user.Email,
-			"role": gin.H{
-				"id":   user.Role.ID,
-				"name": user.Role.Name,
-				"code": user.Role.Code,
-			},
+			"roles":    roleList(user.Roles),
 		},
 	})
 }
@@ -183,3 +228,83 @@ func (c *AuthController) ChangePassword(ctx *gin.Context) {
 
 	utils.SuccessWithMessage(ctx, "密码修改成功", nil)
 }
+
+// Logout 退出登录
+func (c *AuthController) Logout(ctx *gin.Context) {
+	// 从上下文中获取令牌声明
+	claims, exists := ctx.Get("claims")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.authService.Logout(ctx, claims.(*utils.JWTClaims)); err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "退出登录成功", nil)
+}
+
+// LogoutAll 退出所有设备
+func (c *AuthController) LogoutAll(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.authService.LogoutAll(ctx, userID.(uint64)); err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "已退出所有设备", nil)
+}
+
+// JWKS 获取用于验证令牌签名的公钥集合
+func (c *AuthController) JWKS(ctx *gin.Context) {
+	// JWKS为标准格式，不使用统一响应结构包装
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, utils.GetJWKS())
+}
+
+// 从请求中提取客户端信息
+func clientInfo(ctx *gin.Context) *services.ClientInfo {
+	return &services.ClientInfo{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+}
+
+// 登录成功的响应数据，密码登录和短信登录共用
+func loginResponse(accessToken, refreshToken string, user *models.User) gin.H {
+	userInfo := gin.H{
+		"id":       user.ID,
+		"username": user.Username,
+		"nickname": user.Nickname,
+		"email":    user.Email,
+		"phone":    user.Phone,
+		"roles":    roleList(user.Roles),
+	}
+
+	return gin.H{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+		"user":          userInfo,
+	}
+}
+
+// 用户角色的响应数据
+func roleList(roles []*models.Role) []gin.H {
+	list := make([]gin.H, 0, len(roles))
+	for _, role := range roles {
+		list = append(list, gin.H{
+			"id":   role.ID,
+			"name": role.Name,
+			"code": role.Code,
+		})
+	}
+	return list
+}
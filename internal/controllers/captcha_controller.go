@@ -0,0 +1,32 @@
+// Package controllers internal/controllers/captcha_controller.go
+package controllers
+
+import (
+	"star-go/pkg/captcha"
+	"star-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CaptchaController 图形验证码控制器
+type CaptchaController struct {
+	captchaService captcha.Service
+}
+
+// NewCaptchaController 创建图形验证码控制器实例
+func NewCaptchaController() *CaptchaController {
+	return &CaptchaController{
+		captchaService: captcha.NewService(),
+	}
+}
+
+// Generate 生成图形验证码
+func (c *CaptchaController) Generate(ctx *gin.Context) {
+	result, err := c.captchaService.Generate(ctx)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, "生成图形验证码失败: "+err.Error(), nil)
+		return
+	}
+
+	utils.Success(ctx, result)
+}
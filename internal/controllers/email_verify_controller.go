@@ -0,0 +1,82 @@
+// Package controllers internal/controllers/email_verify_controller.go
+package controllers
+
+import (
+	"errors"
+	"star-go/internal/services"
+	"star-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EmailVerifyController 邮箱验证控制器
+type EmailVerifyController struct {
+	emailVerifyService services.IEmailVerifyService
+}
+
+// NewEmailVerifyController 创建邮箱验证控制器实例
+func NewEmailVerifyController() *EmailVerifyController {
+	return &EmailVerifyController{
+		emailVerifyService: services.NewEmailVerifyService(),
+	}
+}
+
+// VerifyEmailRequest 邮箱验证请求，提供验证链接中的令牌或邮箱加验证码
+type VerifyEmailRequest struct {
+	Token string `json:"token" form:"token"`
+	Email string `json:"email" form:"email" binding:"required_without=Token,omitempty,email"`
+	Code  string `json:"code" form:"code" binding:"required_without=Token,omitempty,numeric,min=4,max=10"`
+}
+
+// ResendVerifyEmailRequest 重发验证邮件请求
+type ResendVerifyEmailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// VerifyEmail 验证邮箱，GET用于邮件中的链接，POST用于提交令牌或验证码
+func (c *EmailVerifyController) VerifyEmail(ctx *gin.Context) {
+	var req VerifyEmailRequest
+	var err error
+	if ctx.Request.Method == "GET" {
+		err = ctx.ShouldBindQuery(&req)
+	} else {
+		err = ctx.ShouldBindJSON(&req)
+	}
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	if req.Token != "" {
+		_, err = c.emailVerifyService.VerifyToken(ctx, req.Token)
+	} else {
+		_, err = c.emailVerifyService.VerifyCode(ctx, req.Email, req.Code)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidVerifyToken), errors.Is(err, services.ErrEmailAlreadyVerified):
+			utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		default:
+			utils.FailWithMessage(ctx, smsCodeErrorCode(err, utils.ERROR), err.Error(), nil)
+		}
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "邮箱验证成功，请登录", nil)
+}
+
+// Resend 重发验证邮件
+func (c *EmailVerifyController) Resend(ctx *gin.Context) {
+	var req ResendVerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	if err := c.emailVerifyService.Resend(ctx, req.Email); err != nil {
+		failSendCode(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "如果该邮箱已注册且尚未验证，验证邮件已发送", nil)
+}
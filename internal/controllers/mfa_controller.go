@@ -0,0 +1,119 @@
+// Package controllers internal/controllers/mfa_controller.go
+package controllers
+
+import (
+	"star-go/internal/services"
+	"star-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MFAController 两步验证控制器
+type MFAController struct {
+	mfaService services.IMFAService
+}
+
+// NewMFAController 创建两步验证控制器实例
+func NewMFAController() *MFAController {
+	return &MFAController{
+		mfaService: services.NewMFAService(),
+	}
+}
+
+// MFACodeRequest 两步验证码请求
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"` // TOTP验证码，关闭两步验证和重新生成恢复码时也可使用恢复码
+}
+
+// SetupTOTP 获取身份验证器绑定密钥和otpauth链接
+func (c *MFAController) SetupTOTP(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	setup, err := c.mfaService.SetupTOTP(ctx, userID.(uint64))
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.Success(ctx, setup)
+}
+
+// EnableTOTP 校验验证码并开启两步验证
+func (c *MFAController) EnableTOTP(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	codes, err := c.mfaService.EnableTOTP(ctx, userID.(uint64), req.Code)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "两步验证已开启，请妥善保存恢复码", gin.H{
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP 关闭两步验证
+func (c *MFAController) DisableTOTP(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.mfaService.DisableTOTP(ctx, userID.(uint64), req.Code); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "两步验证已关闭", nil)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (c *MFAController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req MFACodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	codes, err := c.mfaService.RegenerateRecoveryCodes(ctx, userID.(uint64), req.Code)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "恢复码已重新生成，旧恢复码已失效", gin.H{
+		"recovery_codes": codes,
+	})
+}
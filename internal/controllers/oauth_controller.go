@@ -0,0 +1,169 @@
+// Package controllers internal/controllers/oauth_controller.go
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"star-go/internal/services"
+	"star-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthController 第三方登录控制器
+type OAuthController struct {
+	oauthService services.IOAuthService
+}
+
+// NewOAuthController 创建第三方登录控制器实例
+func NewOAuthController() *OAuthController {
+	return &OAuthController{
+		oauthService: services.NewOAuthService(),
+	}
+}
+
+// OAuthCallbackRequest 授权回调参数
+type OAuthCallbackRequest struct {
+	State            string `form:"state" binding:"required"`
+	Code             string `form:"code"`
+	Error            string `form:"error"`
+	ErrorDescription string `form:"error_description"`
+}
+
+// Providers 获取可用的第三方登录方式
+func (c *OAuthController) Providers(ctx *gin.Context) {
+	utils.Success(ctx, gin.H{
+		"providers": c.oauthService.Providers(),
+	})
+}
+
+// Login 跳转到身份提供方的授权页面
+func (c *OAuthController) Login(ctx *gin.Context) {
+	authURL, err := c.oauthService.AuthURL(ctx, ctx.Param("provider"), 0)
+	if err != nil {
+		failOAuth(ctx, err)
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 身份提供方授权回调，登录模式返回令牌，绑定模式返回关联结果
+func (c *OAuthController) Callback(ctx *gin.Context) {
+	var req OAuthCallbackRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	// 用户拒绝授权或提供方返回错误
+	if req.Error != "" {
+		message := "第三方授权失败: " + req.Error
+		if req.ErrorDescription != "" {
+			message += " " + req.ErrorDescription
+		}
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, message, nil)
+		return
+	}
+	if req.Code == "" {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, "缺少授权码", nil)
+		return
+	}
+
+	result, err := c.oauthService.Callback(ctx, ctx.Param("provider"), req.State, req.Code, clientInfo(ctx))
+	if err != nil {
+		failOAuth(ctx, err)
+		return
+	}
+
+	// 绑定模式
+	if result.Identity != nil {
+		utils.SuccessWithMessage(ctx, "外部账户绑定成功", gin.H{
+			"identity": result.Identity,
+		})
+		return
+	}
+
+	// 开启两步验证时返回挑战令牌
+	if result.Login.MFAToken != "" {
+		utils.SuccessWithMessage(ctx, "请输入两步验证码", gin.H{
+			"mfa_required": true,
+			"mfa_token":    result.Login.MFAToken,
+		})
+		return
+	}
+
+	utils.Success(ctx, loginResponse(result.Login.AccessToken, result.Login.RefreshToken, result.Login.User))
+}
+
+// Link 为当前用户生成绑定外部账户的授权地址
+func (c *OAuthController) Link(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	authURL, err := c.oauthService.AuthURL(ctx, ctx.Param("provider"), userID.(uint64))
+	if err != nil {
+		failOAuth(ctx, err)
+		return
+	}
+
+	utils.Success(ctx, gin.H{
+		"auth_url": authURL,
+	})
+}
+
+// ListIdentities 获取当前用户关联的外部账户
+func (c *OAuthController) ListIdentities(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	identities, err := c.oauthService.ListIdentities(userID.(uint64))
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.Success(ctx, gin.H{
+		"identities": identities,
+	})
+}
+
+// Unlink 解除外部账户关联
+func (c *OAuthController) Unlink(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.oauthService.Unlink(userID.(uint64), ctx.Param("provider")); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "已解除外部账户关联", nil)
+}
+
+// 第三方登录失败的响应
+func failOAuth(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOAuthProviderNotFound):
+		utils.FailWithMessage(ctx, utils.NOT_FOUND, err.Error(), nil)
+	case errors.Is(err, services.ErrOAuthInvalidState),
+		errors.Is(err, services.ErrOAuthNotLinked),
+		errors.Is(err, services.ErrOAuthEmailExists),
+		errors.Is(err, services.ErrOAuthAlreadyLinked):
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, err.Error(), nil)
+	default:
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+	}
+}
@@ -0,0 +1,91 @@
+// Package controllers internal/controllers/password_controller.go
+package controllers
+
+import (
+	"star-go/internal/services"
+	"star-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordResetController 找回密码控制器
+type PasswordResetController struct {
+	resetService services.IPasswordResetService
+}
+
+// NewPasswordResetController 创建找回密码控制器实例
+func NewPasswordResetController() *PasswordResetController {
+	return &PasswordResetController{
+		resetService: services.NewPasswordResetService(),
+	}
+}
+
+// ResetCodeRequest 发送找回密码验证码请求
+type ResetCodeRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=sms email"`
+	Target  string `json:"target" binding:"required"` // 手机号或邮箱
+}
+
+// ResetVerifyRequest 校验找回密码验证码请求
+type ResetVerifyRequest struct {
+	Channel string `json:"channel" binding:"required,oneof=sms email"`
+	Target  string `json:"target" binding:"required"`
+	Code    string `json:"code" binding:"required,numeric,min=4,max=10"`
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Ticket      string `json:"ticket" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,max=128"`
+}
+
+// SendCode 发送找回密码验证码
+func (c *PasswordResetController) SendCode(ctx *gin.Context) {
+	var req ResetCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	if err := c.resetService.SendCode(ctx, req.Channel, req.Target, ctx.ClientIP()); err != nil {
+		failSendCode(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "如果该账户存在，验证码已发送", nil)
+}
+
+// VerifyCode 校验验证码并获取重置凭证
+func (c *PasswordResetController) VerifyCode(ctx *gin.Context) {
+	var req ResetVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	ticket, err := c.resetService.VerifyCode(ctx, req.Channel, req.Target, req.Code)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	utils.Success(ctx, gin.H{
+		"ticket": ticket,
+	})
+}
+
+// ResetPassword 使用重置凭证设置新密码
+func (c *PasswordResetController) ResetPassword(ctx *gin.Context) {
+	var req ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	if err := c.resetService.ResetPassword(ctx, req.Ticket, req.NewPassword); err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "密码重置成功，请重新登录", nil)
+}
@@ -0,0 +1,123 @@
+// Package controllers internal/controllers/phone_controller.go
+package controllers
+
+import (
+	"star-go/internal/services"
+	"star-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PhoneController 手机号绑定控制器
+type PhoneController struct {
+	phoneService services.IPhoneService
+}
+
+// NewPhoneController 创建手机号绑定控制器实例
+func NewPhoneController() *PhoneController {
+	return &PhoneController{
+		phoneService: services.NewPhoneService(),
+	}
+}
+
+// VerifyOldPhoneRequest 校验原手机号请求
+type VerifyOldPhoneRequest struct {
+	Code string `json:"code" binding:"required,numeric,min=4,max=10"`
+}
+
+// NewPhoneCodeRequest 发送新手机号验证码请求
+type NewPhoneCodeRequest struct {
+	Phone string `json:"phone" binding:"required,phone"`
+}
+
+// ChangePhoneRequest 换绑手机号请求
+type ChangePhoneRequest struct {
+	Phone string `json:"phone" binding:"required,phone"`
+	Code  string `json:"code" binding:"required,numeric,min=4,max=10"`
+}
+
+// SendOldPhoneCode 向原手机号发送验证码
+func (c *PhoneController) SendOldPhoneCode(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.phoneService.SendOldPhoneCode(ctx, userID.(uint64), ctx.ClientIP()); err != nil {
+		failSendCode(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "验证码发送成功", nil)
+}
+
+// VerifyOldPhone 校验原手机号验证码
+func (c *PhoneController) VerifyOldPhone(ctx *gin.Context) {
+	var req VerifyOldPhoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.phoneService.VerifyOldPhone(ctx, userID.(uint64), req.Code); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "原手机号验证成功", nil)
+}
+
+// SendNewPhoneCode 向新手机号发送验证码
+func (c *PhoneController) SendNewPhoneCode(ctx *gin.Context) {
+	var req NewPhoneCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.phoneService.SendNewPhoneCode(ctx, userID.(uint64), req.Phone, ctx.ClientIP()); err != nil {
+		failSendCode(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "验证码发送成功", nil)
+}
+
+// ChangePhone 换绑手机号
+func (c *PhoneController) ChangePhone(ctx *gin.Context) {
+	var req ChangePhoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	if err := c.phoneService.ChangePhone(ctx, userID.(uint64), req.Phone, req.Code); err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "手机号绑定成功", nil)
+}
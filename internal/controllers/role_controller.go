@@ -0,0 +1,276 @@
+// Package controllers internal/controllers/role_controller.go
+package controllers
+
+import (
+	"errors"
+	"star-go/internal/models"
+	"star-go/internal/services"
+	"star-go/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleController 角色管理控制器
+type RoleController struct {
+	roleService services.IRoleService
+}
+
+// NewRoleController 创建角色管理控制器实例
+func NewRoleController() *RoleController {
+	return &RoleController{
+		roleService: services.NewRoleService(),
+	}
+}
+
+// CreateRoleRequest 角色创建请求
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=50"`
+	Code        string   `json:"code" binding:"required,min=2,max=50,alphanum"`
+	Description string   `json:"description" binding:"max=200"`
+	Permissions []string `json:"permissions"`
+	ParentID    *uint64  `json:"parent_id"`
+}
+
+// UpdateRoleRequest 角色更新请求
+type UpdateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=50"`
+	Code        string   `json:"code" binding:"required,min=2,max=50,alphanum"`
+	Description string   `json:"description" binding:"max=200"`
+	Permissions []string `json:"permissions"`
+	ParentID    *uint64  `json:"parent_id"`
+}
+
+// RolePermissionRequest 角色权限请求
+type RolePermissionRequest struct {
+	Permission string `json:"permission" binding:"required,max=100"`
+}
+
+// GetRoles 获取角色列表
+func (c *RoleController) GetRoles(ctx *gin.Context) {
+	// 获取分页参数
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+	search := ctx.DefaultQuery("search", "")
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+
+	roles, total, err := c.roleService.ListRoles(page, pageSize, search)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.Success(ctx, gin.H{
+		"list":  roles,
+		"total": total,
+		"page":  page,
+		"size":  pageSize,
+	})
+}
+
+// GetRole 根据ID获取角色
+func (c *RoleController) GetRole(ctx *gin.Context) {
+	id, ok := roleIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	role, err := c.roleService.GetRoleByID(id)
+	if err != nil {
+		failRole(ctx, err)
+		return
+	}
+
+	utils.Success(ctx, newRoleDetail(role))
+}
+
+// CreateRole 创建角色
+func (c *RoleController) CreateRole(ctx *gin.Context) {
+	var req CreateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Code:        req.Code,
+		Description: req.Description,
+		Permissions: models.Permissions(req.Permissions),
+		ParentID:    req.ParentID,
+	}
+	grantor, ok := requestGrantor(ctx)
+	if !ok {
+		return
+	}
+	if err := c.roleService.CreateRole(grantor, role); err != nil {
+		failRole(ctx, err)
+		return
+	}
+
+	utils.Success(ctx, newRoleDetail(role))
+}
+
+// UpdateRole 更新角色
+func (c *RoleController) UpdateRole(ctx *gin.Context) {
+	id, ok := roleIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Code:        req.Code,
+		Description: req.Description,
+		Permissions: models.Permissions(req.Permissions),
+		ParentID:    req.ParentID,
+	}
+	role.ID = id
+	grantor, ok := requestGrantor(ctx)
+	if !ok {
+		return
+	}
+	if err := c.roleService.UpdateRole(grantor, role); err != nil {
+		failRole(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "角色更新成功", newRoleDetail(role))
+}
+
+// DeleteRole 删除角色
+func (c *RoleController) DeleteRole(ctx *gin.Context) {
+	id, ok := roleIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	if err := c.roleService.DeleteRole(id); err != nil {
+		failRole(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "角色删除成功", nil)
+}
+
+// AddPermission 为角色添加权限
+func (c *RoleController) AddPermission(ctx *gin.Context) {
+	id, ok := roleIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req RolePermissionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+
+	grantor, ok := requestGrantor(ctx)
+	if !ok {
+		return
+	}
+	role, err := c.roleService.AddPermission(grantor, id, req.Permission)
+	if err != nil {
+		failRole(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "权限添加成功", newRoleDetail(role))
+}
+
+// RemovePermission 移除角色的权限，权限标识通过路径参数传递（需URL编码）
+func (c *RoleController) RemovePermission(ctx *gin.Context) {
+	id, ok := roleIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	grantor, ok := requestGrantor(ctx)
+	if !ok {
+		return
+	}
+	role, err := c.roleService.RemovePermission(grantor, id, ctx.Param("permission"))
+	if err != nil {
+		failRole(ctx, err)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "权限移除成功", newRoleDetail(role))
+}
+
+// 角色详情，额外返回包含继承权限在内的有效权限
+type roleDetail struct {
+	*models.Role
+	EffectivePermissions models.Permissions `json:"effective_permissions"`
+}
+
+// 创建角色详情响应
+func newRoleDetail(role *models.Role) roleDetail {
+	return roleDetail{
+		Role:                 role,
+		EffectivePermissions: role.EffectivePermissions(),
+	}
+}
+
+// 解析路径中的角色ID
+func roleIDParam(ctx *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, "无效的角色ID", nil)
+		return 0, false
+	}
+	return id, true
+}
+
+// 获取当前请求用户的有效权限，由权限中间件解析后保存在上下文中，用于校验授予的权限；
+// 使用API密钥时按密钥的授权范围收窄
+func requestGrantor(ctx *gin.Context) (*services.UserPermissions, bool) {
+	value, exists := ctx.Get("permissions")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.FORBIDDEN, "无法获取当前用户的权限", nil)
+		return nil, false
+	}
+	grantor := value.(*services.UserPermissions)
+	if key, isAPIKey := ctx.Get("apiKey"); isAPIKey {
+		grantor = grantor.WithScopes(key.(*models.APIKey).Scopes)
+	}
+	return grantor, true
+}
+
+// 授权校验失败时返回403，其他错误使用指定的错误码
+func failGrant(ctx *gin.Context, err error, code int) {
+	if errors.Is(err, services.ErrPermissionNotHeld) || errors.Is(err, services.ErrSuperuserOnly) {
+		utils.FailWithMessage(ctx, utils.FORBIDDEN, err.Error(), nil)
+		return
+	}
+	utils.FailWithMessage(ctx, code, err.Error(), nil)
+}
+
+// 角色管理失败的响应
+func failRole(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		utils.FailWithMessage(ctx, utils.NOT_FOUND, err.Error(), nil)
+	case errors.Is(err, services.ErrRoleBuiltin),
+		errors.Is(err, services.ErrRoleInUse),
+		errors.Is(err, services.ErrRoleProtected),
+		errors.Is(err, services.ErrRoleHasChild),
+		errors.Is(err, services.ErrPermissionNotHeld),
+		errors.Is(err, services.ErrSuperuserOnly):
+		utils.FailWithMessage(ctx, utils.FORBIDDEN, err.Error(), nil)
+	default:
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+	}
+}
@@ -0,0 +1,126 @@
+// Package controllers internal/controllers/session_controller.go
+package controllers
+
+import (
+	"star-go/internal/models"
+	"star-go/internal/services"
+	"star-go/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SessionController 设备会话控制器
+type SessionController struct {
+	sessionService services.ISessionService
+}
+
+// NewSessionController 创建设备会话控制器实例
+func NewSessionController() *SessionController {
+	return &SessionController{
+		sessionService: services.NewSessionService(),
+	}
+}
+
+// ListMySessions 获取当前用户的登录设备列表
+func (c *SessionController) ListMySessions(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	c.listSessions(ctx, userID.(uint64))
+}
+
+// RevokeMySession 撤销当前用户的指定登录设备
+func (c *SessionController) RevokeMySession(ctx *gin.Context) {
+	// 从上下文中获取用户ID
+	userID, exists := ctx.Get("userID")
+	if !exists {
+		utils.FailWithMessage(ctx, utils.UNAUTHORIZED, "未找到用户信息", nil)
+		return
+	}
+
+	c.revokeSession(ctx, userID.(uint64), ctx.Param("id"))
+}
+
+// ListUserSessions 管理员获取指定用户的登录设备列表
+func (c *SessionController) ListUserSessions(ctx *gin.Context) {
+	// 获取用户ID
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, "无效的用户ID", nil)
+		return
+	}
+
+	c.listSessions(ctx, userID)
+}
+
+// RevokeUserSession 管理员撤销指定用户的登录设备
+func (c *SessionController) RevokeUserSession(ctx *gin.Context) {
+	// 获取用户ID
+	userID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, "无效的用户ID", nil)
+		return
+	}
+
+	c.revokeSession(ctx, userID, ctx.Param("sid"))
+}
+
+// 返回用户的会话列表，并标记当前请求所属的会话
+func (c *SessionController) listSessions(ctx *gin.Context, userID uint64) {
+	sessions, err := c.sessionService.ListSessions(ctx, userID)
+	if err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	// 当前请求所属会话
+	var currentSessionID string
+	if claims, exists := ctx.Get("claims"); exists {
+		currentSessionID = claims.(*utils.JWTClaims).SessionID
+	}
+
+	// 构造响应数据
+	sessionList := make([]gin.H, 0, len(sessions))
+	for _, session := range sessions {
+		sessionList = append(sessionList, sessionResponse(session, session.ID == currentSessionID))
+	}
+
+	utils.Success(ctx, gin.H{
+		"list":  sessionList,
+		"total": len(sessionList),
+	})
+}
+
+// 撤销会话
+func (c *SessionController) revokeSession(ctx *gin.Context, userID uint64, sessionID string) {
+	if sessionID == "" {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, "无效的会话ID", nil)
+		return
+	}
+
+	if err := c.sessionService.RevokeSession(ctx, userID, sessionID); err != nil {
+		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+		return
+	}
+
+	utils.SuccessWithMessage(ctx, "设备已下线", nil)
+}
+
+// 会话响应数据
+func sessionResponse(session *models.UserSession, current bool) gin.H {
+	return gin.H{
+		"id":           session.ID,
+		"device":       session.Device,
+		"user_agent":   session.UserAgent,
+		"ip":           session.IP,
+		"created_at":   session.CreatedAt,
+		"last_seen_at": session.LastSeenAt,
+		"expires_at":   session.ExpiresAt,
+		"current":      current,
+	}
+}
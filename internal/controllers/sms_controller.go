@@ -1,25 +1,34 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"math"
 	"star-go/internal/services"
+	"star-go/pkg/cache"
+	"star-go/pkg/phonenumber"
+	"star-go/pkg/sms"
 	"star-go/pkg/utils"
+	"strconv"
+	"time"
 )
 
 type SMSController struct {
-	smsService services.SMSService
+	smsService  services.SMSService
+	authService services.IAuthService
 }
 
 func NewSMSController() *SMSController {
 	return &SMSController{
-		services.NewSMSService(),
+		smsService:  services.NewSMSService(),
+		authService: services.NewAuthService(),
 	}
 }
 
 type SendCodeRequest struct {
 	Biz   string `json:"biz" binding:"required"`
-	Phone string `json:"phone" binding:"required,len=11"`
+	Phone string `json:"phone" binding:"required,phone"`
 }
 
 // SendCodeResponse 发送验证码响应
@@ -31,8 +40,8 @@ type SendCodeResponse struct {
 // VerifyCodeRequest 验证码验证请求
 type VerifyCodeRequest struct {
 	Biz   string `json:"biz" binding:"required"`
-	Phone string `json:"phone" binding:"required,len=11"`
-	Code  string `json:"code" binding:"required,len=6"`
+	Phone string `json:"phone" binding:"required,phone"`
+	Code  string `json:"code" binding:"required,numeric,min=4,max=10"`
 }
 
 // VerifyCodeResponse 验证码验证响应
@@ -55,9 +64,9 @@ func (c *SMSController) SendCode(ctx *gin.Context) {
 	}
 
 	//发送验证码
-	err := c.smsService.Send(ctx, req.Biz, req.Phone)
+	err := c.smsService.Send(ctx, req.Biz, req.Phone, ctx.ClientIP())
 	if err != nil {
-		utils.Fail(ctx, utils.ERROR, fmt.Errorf("验证码发送失败:%s", err.Error()))
+		failSendCode(ctx, err)
 		return
 	}
 	utils.Success(ctx, gin.H{
@@ -82,14 +91,11 @@ func (c *SMSController) VerifyCode(ctx *gin.Context) {
 	//验证验证码
 	isVerify, err := c.smsService.Verify(ctx, req.Biz, req.Phone, req.Code)
 	if err != nil {
-		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), gin.H{"code": "手机号不存在"})
+		utils.FailWithMessage(ctx, smsCodeErrorCode(err, utils.ERROR), err.Error(), nil)
 		return
 	}
 	if !isVerify {
-		utils.Fail(ctx, utils.INVALID_PARAMS, gin.H{
-			"Fail":    true,
-			"Message": "验证码错误",
-		})
+		utils.FailWithMessage(ctx, utils.CODE_MISMATCH, cache.ErrCodeMismatch.Error(), nil)
 		return
 	}
 	utils.Success(ctx, gin.H{
@@ -98,6 +104,62 @@ func (c *SMSController) VerifyCode(ctx *gin.Context) {
 	})
 }
 
+// SMSLoginRequest 短信验证码登录请求
+type SMSLoginRequest struct {
+	Biz   string `json:"biz" binding:"omitempty,oneof=login register"`
+	Phone string `json:"phone" binding:"required,phone"`
+	Code  string `json:"code" binding:"required,numeric,min=4,max=10"`
+}
+
+// Login 手机号验证码登录，biz为register时未注册的手机号将自动注册
+func (c *SMSController) Login(ctx *gin.Context) {
+	var req SMSLoginRequest
+	if err := ctx.ShouldBindBodyWithJSON(&req); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
+		return
+	}
+	if req.Biz == "" {
+		req.Biz = services.BizLogin
+	}
+
+	result, err := c.authService.LoginBySMS(ctx, req.Biz, req.Phone, req.Code, clientInfo(ctx))
+	if err != nil {
+		utils.FailWithMessage(ctx, smsCodeErrorCode(err, utils.ERROR), err.Error(), nil)
+		return
+	}
+
+	// 开启两步验证时返回挑战令牌，由客户端提交验证码完成登录
+	if result.MFAToken != "" {
+		utils.SuccessWithMessage(ctx, "请输入两步验证码", gin.H{
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		})
+		return
+	}
+
+	utils.Success(ctx, loginResponse(result.AccessToken, result.RefreshToken, result.User))
+}
+
+// Inbox 查看进程内收件箱中的短信，仅用于开发和测试环境
+func (c *SMSController) Inbox(ctx *gin.Context) {
+	inbox, ok := sms.GetInbox()
+	if !ok {
+		utils.FailWithMessage(ctx, utils.NOT_FOUND, "未启用内存短信收件箱", nil)
+		return
+	}
+
+	phone := ctx.Query("phone")
+	if phone != "" {
+		phone = phonenumber.Canonical(phone)
+	}
+
+	messages := inbox.Messages(phone)
+	utils.Success(ctx, gin.H{
+		"list":  messages,
+		"total": len(messages),
+	})
+}
+
 // 验证业务类型是否有效
 func isValidBizType(biz string) bool {
 	validBizTypes := map[string]bool{
@@ -108,3 +170,39 @@ func isValidBizType(biz string) bool {
 	}
 	return validBizTypes[biz]
 }
+
+// 将验证码校验错误映射为响应码，非验证码错误返回fallback
+func smsCodeErrorCode(err error, fallback int) int {
+	switch {
+	case errors.Is(err, cache.ErrCodeExpired):
+		return utils.CODE_EXPIRED
+	case errors.Is(err, cache.ErrTooManyAttempts):
+		return utils.CODE_TOO_MANY_ATTEMPTS
+	case errors.Is(err, cache.ErrCodeMismatch):
+		return utils.CODE_MISMATCH
+	default:
+		return fallback
+	}
+}
+
+// 验证码发送失败的响应，频率受限时返回429并携带重试等待时间
+func failSendCode(ctx *gin.Context, err error) {
+	var retryAfterDuration time.Duration
+	var smsLimitErr *services.SMSRateLimitError
+	var emailLimitErr *services.EmailRateLimitError
+	switch {
+	case errors.As(err, &smsLimitErr):
+		retryAfterDuration = smsLimitErr.RetryAfter
+	case errors.As(err, &emailLimitErr):
+		retryAfterDuration = emailLimitErr.RetryAfter
+	}
+	if retryAfterDuration > 0 {
+		retryAfter := int(math.Ceil(retryAfterDuration.Seconds()))
+		ctx.Header("Retry-After", strconv.Itoa(retryAfter))
+		utils.FailWithMessage(ctx, utils.TOO_MANY_REQUESTS, err.Error(), gin.H{
+			"retry_after": retryAfter,
+		})
+		return
+	}
+	utils.FailWithMessage(ctx, utils.ERROR, fmt.Sprintf("验证码发送失败:%s", err.Error()), nil)
+}
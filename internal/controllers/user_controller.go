@@ -12,30 +12,32 @@ import (
 
 // 用户控制器
 type UserController struct {
-	userService services.IUserService
+	userService     services.IUserService
+	passwordService services.IPasswordService
 }
 
 // 创建用户控制器实例
 func NewUserController() *UserController {
 	return &UserController{
-		userService: services.NewUserService(),
+		userService:     services.NewUserService(),
+		passwordService: services.NewPasswordService(),
 	}
 }
 
 // 用户创建请求
 type CreateUserRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
-	Password string `json:"password" binding:"required,min=6,max=20"`
+	Password string `json:"password" binding:"required,max=128"`
 	Email    string `json:"email" binding:"required,email"`
 	Nickname string `json:"nickname" binding:"required,min=2,max=50"`
-	RoleID   uint   `json:"role_id" binding:"required"`
+	RoleIDs  []uint64 `json:"role_ids" binding:"required,min=1"`
 }
 
 // 用户更新请求
 type UpdateUserRequest struct {
 	Nickname string `json:"nickname" binding:"required,min=2,max=50"`
 	Email    string `json:"email" binding:"required,email"`
-	RoleID   uint   `json:"role_id" binding:"required"`
+	RoleIDs  []uint64 `json:"role_ids" binding:"required,min=1"`
 }
 
 // 获取用户列表
@@ -60,11 +62,7 @@ func (c *UserController) GetUsers(ctx *gin.Context) {
 			"username": user.Username,
 			"email":    user.Email,
 			"nickname": user.Nickname,
-			"role": gin.H{
-				"id":   user.Role.ID,
-				"name": user.Role.Name,
-				"code": user.Role.Code,
-			},
+			"roles":    roleList(user.Roles),
 			"created_at": user.CreatedAt,
 			"updated_at": user.UpdatedAt,
 		})
@@ -105,11 +103,7 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 		"username": user.Username,
 		"email":    user.Email,
 		"nickname": user.Nickname,
-		"role": gin.H{
-			"id":   user.Role.ID,
-			"name": user.Role.Name,
-			"code": user.Role.Code,
-		},
+		"roles":    roleList(user.Roles),
 		"created_at": user.CreatedAt,
 		"updated_at": user.UpdatedAt,
 	})
@@ -123,17 +117,30 @@ func (c *UserController) CreateUser(ctx *gin.Context) {
 		return
 	}
 
+	// 查找角色，只能分配自己可以授予的角色
+	grantor, ok := requestGrantor(ctx)
+	if !ok {
+		return
+	}
+	roles, err := c.userService.FindAssignableRoles(grantor, req.RoleIDs)
+	if err != nil {
+		failGrant(ctx, err, utils.INVALID_PARAMS)
+		return
+	}
+
 	// 创建用户对象
 	user := &models.User{
 		Username: req.Username,
 		Email:    req.Email,
 		Nickname: req.Nickname,
-		RoleID:   req.RoleID,
+		Roles:    roles,
 	}
+	// 管理员创建的账户无需验证邮箱
+	user.EmailVerified = true
 
-	// 设置密码
-	if err := user.SetPassword(req.Password); err != nil {
-		utils.FailWithMessage(ctx, utils.ERROR, "密码设置失败: "+err.Error(), nil)
+	// 按密码策略设置密码
+	if err := c.passwordService.SetPassword(user, req.Password); err != nil {
+		utils.FailWithMessage(ctx, utils.INVALID_PARAMS, err.Error(), nil)
 		return
 	}
 
@@ -177,14 +184,24 @@ func (c *UserController) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
+	// 查找角色，任意角色不存在或无权授予时不做任何修改
+	grantor, ok := requestGrantor(ctx)
+	if !ok {
+		return
+	}
+	roles, err := c.userService.FindAssignableRoles(grantor, req.RoleIDs)
+	if err != nil {
+		failGrant(ctx, err, utils.INVALID_PARAMS)
+		return
+	}
+
 	// 更新用户信息
 	user.Nickname = req.Nickname
 	user.Email = req.Email
-	user.RoleID = req.RoleID
 
-	// 保存更新
-	if err := c.userService.UpdateUser(user); err != nil {
-		utils.FailWithMessage(ctx, utils.ERROR, err.Error(), nil)
+	// 在同一事务中保存用户信息和角色
+	if err := c.userService.UpdateUserWithRoles(grantor, user, roles); err != nil {
+		failGrant(ctx, err, utils.ERROR)
 		return
 	}
 
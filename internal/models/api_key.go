@@ -0,0 +1,28 @@
+// Package models internal/models/api_key.go
+package models
+
+import "time"
+
+// APIKey 用户创建的API密钥，供脚本和CI调用接口
+// 密钥明文只在创建时返回一次，数据库仅保存前缀和哈希
+type APIKey struct {
+	BaseModel
+	UserID     uint64      `gorm:"index;not null" json:"user_id"`              // 所属用户ID
+	Name       string      `gorm:"size:100;not null" json:"name"`              // 密钥名称
+	Prefix     string      `gorm:"size:32;uniqueIndex;not null" json:"prefix"` // 密钥前缀，用于识别和查找
+	KeyHash    string      `gorm:"size:64;not null" json:"-"`                  // 密钥SHA-256哈希
+	Scopes     Permissions `gorm:"type:json" json:"scopes"`                    // 授权范围，为所属用户权限的子集
+	ExpiresAt  *time.Time  `gorm:"index" json:"expires_at"`                    // 过期时间，为空表示永不过期
+	LastUsedAt *time.Time  `json:"last_used_at"`                               // 最近使用时间
+	LastUsedIP string      `gorm:"size:64" json:"last_used_ip"`                // 最近使用IP
+}
+
+// TableName 表名
+func (APIKey) TableName() string {
+	return "star_api_keys"
+}
+
+// IsExpired 密钥是否已过期
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && !time.Now().Before(*k.ExpiresAt)
+}
@@ -0,0 +1,17 @@
+// Package models internal/models/identity.go
+package models
+
+// UserIdentity 用户关联的外部身份，同一提供方的同一外部账户只能关联一个用户
+type UserIdentity struct {
+	BaseModel
+	UserID   uint64 `gorm:"index;not null" json:"user_id"`                                              // 用户ID
+	Provider string `gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject" json:"provider"` // 身份提供方标识
+	Subject  string `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject" json:"-"`       // 外部账户唯一标识（sub）
+	Email    string `gorm:"size:100" json:"email"`                                                      // 外部账户邮箱
+	Name     string `gorm:"size:100" json:"name"`                                                       // 外部账户名称
+}
+
+// TableName 表名
+func (UserIdentity) TableName() string {
+	return "star_user_identities"
+}
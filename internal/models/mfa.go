@@ -0,0 +1,17 @@
+// Package models internal/models/mfa.go
+package models
+
+import "time"
+
+// UserRecoveryCode 两步验证恢复码，仅保存哈希，每个恢复码只能使用一次
+type UserRecoveryCode struct {
+	BaseModel
+	UserID   uint64     `gorm:"index;not null" json:"user_id"` // 用户ID
+	CodeHash string     `gorm:"size:64;not null" json:"-"`     // 恢复码SHA-256哈希
+	UsedAt   *time.Time `json:"used_at,omitempty"`             // 使用时间
+}
+
+// TableName 表名
+func (UserRecoveryCode) TableName() string {
+	return "star_user_recovery_codes"
+}
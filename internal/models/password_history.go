@@ -0,0 +1,14 @@
+// Package models internal/models/password_history.go
+package models
+
+// UserPasswordHistory 用户历史密码哈希，用于禁止重复使用近期密码
+type UserPasswordHistory struct {
+	BaseModel
+	UserID       uint64 `gorm:"index;not null" json:"user_id"` // 用户ID
+	PasswordHash string `gorm:"size:255;not null" json:"-"`    // 密码哈希
+}
+
+// TableName 表名
+func (UserPasswordHistory) TableName() string {
+	return "star_user_password_histories"
+}
@@ -5,6 +5,8 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 )
 
 // 权限常量
@@ -14,20 +16,28 @@ const (
 	PermUserCreate = "user:create" // 创建用户
 	PermUserEdit   = "user:edit"   // 编辑用户
 	PermUserDelete = "user:delete" // 删除用户
+	PermUserAll    = "user:*"      // 所有用户管理权限
 
 	// 内容相关权限
 	PermContentView   = "content:view"   // 查看内容
 	PermContentCreate = "content:create" // 创建内容
 	PermContentEdit   = "content:edit"   // 编辑内容
 	PermContentDelete = "content:delete" // 删除内容
+	PermContentAll    = "content:*"      // 所有内容管理权限
 
 	// 系统相关权限
 	PermSystemConfig = "system:config" // 系统配置
 	PermSystemLog    = "system:log"    // 系统日志
 	PermSystemBackup = "system:backup" // 系统备份
 
+	// 角色相关权限
+	PermRoleManage = "role:manage" // 管理角色
+
 	// 全部权限
 	PermAll = "*" // 所有权限
+
+	// 拒绝权限前缀，如 !system:backup 表示明确拒绝系统备份权限
+	PermDenyPrefix = "!"
 )
 
 // 根据角色编码获取预定义角色
@@ -44,6 +54,68 @@ func GetPredefinedRole(code string) *Role {
 	}
 }
 
+// IsBuiltinRole 是否为系统内置角色，内置角色不能删除或修改编码
+func IsBuiltinRole(code string) bool {
+	switch code {
+	case RoleSuperuser, RoleAdmin, RoleUser, RoleGuest:
+		return true
+	default:
+		return false
+	}
+}
+
+// ValidatePermission 校验权限标识格式，权限由冒号分隔的段组成，如 user:view；
+// 整段为 * 表示通配，以 ! 开头表示拒绝该权限
+func ValidatePermission(permission string) error {
+	if permission == "" || len(permission) > 100 {
+		return errors.New("权限标识长度必须在1到100之间")
+	}
+	pattern := strings.TrimPrefix(permission, PermDenyPrefix)
+	if pattern == PermAll {
+		return nil
+	}
+	for _, segment := range strings.Split(pattern, ":") {
+		if segment == "" {
+			return fmt.Errorf("无效的权限标识: %s", permission)
+		}
+		if segment == PermAll {
+			continue
+		}
+		for _, r := range segment {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+				return fmt.Errorf("无效的权限标识: %s", permission)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchPermission 检查权限模式是否匹配指定权限，按冒号分段比较：
+// 模式中的 * 匹配任意一段，位于末尾时匹配剩余的一段或多段，如 user:* 匹配 user:view，content:*:own 匹配 content:edit:own
+func MatchPermission(pattern, permission string) bool {
+	if pattern == permission {
+		return true
+	}
+
+	patternSegments := strings.Split(pattern, ":")
+	permissionSegments := strings.Split(permission, ":")
+	for i, segment := range patternSegments {
+		if i >= len(permissionSegments) {
+			return false
+		}
+		if segment == PermAll {
+			if i == len(patternSegments)-1 {
+				return true
+			}
+			continue
+		}
+		if segment != permissionSegments[i] {
+			return false
+		}
+	}
+	return len(patternSegments) == len(permissionSegments)
+}
+
 // Permissions 权限类型 - 使用字符串切片存储权限
 type Permissions []string
 
@@ -65,14 +137,21 @@ func (p Permissions) Value() (driver.Value, error) {
 	return json.Marshal(p)
 }
 
-// HasPermission 检查是否包含指定权限
+// HasPermission 检查是否包含指定权限，支持通配符模式；拒绝项优先，只要匹配任意拒绝项即视为没有该权限
 func (p Permissions) HasPermission(permission string) bool {
+	allowed := false
 	for _, perm := range p {
-		if perm == permission || perm == "*" {
-			return true
+		if strings.HasPrefix(perm, PermDenyPrefix) {
+			if MatchPermission(strings.TrimPrefix(perm, PermDenyPrefix), permission) {
+				return false
+			}
+			continue
+		}
+		if !allowed && MatchPermission(perm, permission) {
+			allowed = true
 		}
 	}
-	return false
+	return allowed
 }
 
 // 添加权限
@@ -102,7 +181,9 @@ type Role struct {
 	Name        string      `gorm:"size:50;uniqueIndex;not null" json:"name"` // 角色名称
 	Code        string      `gorm:"size:50;uniqueIndex;not null" json:"code"` // 角色编码
 	Description string      `gorm:"size:200" json:"description"`              // 角色描述
-	Permissions Permissions `gorm:"type:json" json:"permissions"`             // 角色权限
+	Permissions Permissions `gorm:"type:json" json:"permissions"`             // 角色自身的权限
+	ParentID    *uint64     `gorm:"index" json:"parent_id"`                   // 父角色ID，继承父角色的全部权限
+	Parent      *Role       `gorm:"foreignKey:ParentID" json:"-"`             // 父角色，由仓库按需加载
 }
 
 // 表名
@@ -110,9 +191,23 @@ func (Role) TableName() string {
 	return "star_roles"
 }
 
-// 检查是否有指定权限
+// 检查是否有指定权限，包含从父角色继承的权限
 func (r *Role) HasPermission(permission string) bool {
-	return r.Permissions.HasPermission(permission)
+	return r.EffectivePermissions().HasPermission(permission)
+}
+
+// EffectivePermissions 获取角色的有效权限，即自身权限与所有祖先角色权限的并集，需已加载父角色
+func (r *Role) EffectivePermissions() Permissions {
+	effective := Permissions{}
+	visited := make(map[*Role]bool)
+	// 记录已访问的角色，数据异常形成循环时也能终止
+	for role := r; role != nil && !visited[role]; role = role.Parent {
+		visited[role] = true
+		for _, permission := range role.Permissions {
+			effective.AddPermission(permission)
+		}
+	}
+	return effective
 }
 
 // 添加权限
@@ -125,28 +220,34 @@ func (r *Role) RemovePermission(permission string) {
 	r.Permissions.RemovePermission(permission)
 }
 
-// 预定义角色
+// 预定义角色，权限逐级继承：访客 ⊂ 普通用户 ⊂ 管理员
 var (
-	// 管理员角色 - 拥有所有权限
+	// 管理员角色 - 在普通用户权限基础上拥有用户、内容、角色和系统配置的管理权限
 	AdminRole = &Role{
 		Name:        "管理员",
 		Code:        RoleAdmin,
-		Description: "系统管理员，拥有所有权限",
-		Permissions: Permissions{"*"},
+		Description: "系统管理员，拥有用户、内容和角色的管理权限",
+		Permissions: Permissions{
+			"user:*",
+			"content:*",
+			"role:manage",
+			"system:config",
+			"system:log",
+		},
+		Parent: UserRole,
 	}
 
-	// 普通用户角色 - 拥有基本权限
+	// 普通用户角色 - 在访客权限基础上拥有基本权限
 	UserRole = &Role{
 		Name:        "普通用户",
 		Code:        RoleUser,
 		Description: "普通用户，拥有基本权限",
 		Permissions: Permissions{
-			"user:view",
 			"user:edit",
-			"content:view",
 			"content:create",
 			"content:edit",
 		},
+		Parent: GuestRole,
 	}
 
 	// 访客角色 - 仅拥有查看权限
@@ -0,0 +1,72 @@
+// Package models internal/models/role_test.go
+package models
+
+import "testing"
+
+func TestPermissionsHasPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions Permissions
+		permission  string
+		want        bool
+	}{
+		{"精确匹配", Permissions{"user:view"}, "user:view", true},
+		{"精确不匹配", Permissions{"user:view"}, "user:edit", false},
+		{"空权限", Permissions{}, "user:view", false},
+		{"全局通配", Permissions{"*"}, "system:backup", true},
+		{"全局通配匹配多段", Permissions{"*"}, "content:edit:own", true},
+		{"末尾通配匹配一段", Permissions{"user:*"}, "user:view", true},
+		{"末尾通配匹配多段", Permissions{"user:*"}, "user:profile:edit", true},
+		{"末尾通配不匹配前缀本身", Permissions{"user:*"}, "user", false},
+		{"末尾通配不匹配其他模块", Permissions{"user:*"}, "content:view", false},
+		{"中间通配", Permissions{"content:*:own"}, "content:edit:own", true},
+		{"中间通配段数不足", Permissions{"content:*:own"}, "content:edit", false},
+		{"中间通配段数过多", Permissions{"content:*:own"}, "content:edit:own:draft", false},
+		{"中间通配末段不匹配", Permissions{"content:*:own"}, "content:edit:all", false},
+		{"精确权限段数不同", Permissions{"user:view"}, "user:view:all", false},
+		{"通配符不作为被检查权限的通配", Permissions{"user:view"}, "user:*", false},
+		{"拒绝项覆盖全局通配", Permissions{"*", "!system:backup"}, "system:backup", false},
+		{"拒绝项不影响其他权限", Permissions{"*", "!system:backup"}, "system:log", true},
+		{"拒绝项覆盖模块通配", Permissions{"system:*", "!system:backup"}, "system:backup", false},
+		{"拒绝项顺序无关", Permissions{"!system:backup", "system:backup"}, "system:backup", false},
+		{"通配拒绝项覆盖精确授予", Permissions{"system:log", "!system:*"}, "system:log", false},
+		{"仅有拒绝项", Permissions{"!system:backup"}, "system:log", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.permissions.HasPermission(tt.permission); got != tt.want {
+				t.Errorf("%v.HasPermission(%q) = %v, want %v", tt.permissions, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePermission(t *testing.T) {
+	tests := []struct {
+		permission string
+		wantErr    bool
+	}{
+		{"user:view", false},
+		{"user:*", false},
+		{"content:*:own", false},
+		{"*", false},
+		{"!system:backup", false},
+		{"!*", false},
+		{"", true},
+		{"!", true},
+		{"!!system:backup", true},
+		{"user:", true},
+		{"user::view", true},
+		{"us*er", true},
+		{"User:View", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.permission, func(t *testing.T) {
+			if err := ValidatePermission(tt.permission); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePermission(%q) err = %v, wantErr %v", tt.permission, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+// Package models internal/models/session.go
+package models
+
+import "time"
+
+// UserSession 用户登录会话，每次登录对应一个设备会话
+// 会话ID同时作为刷新令牌族ID，撤销会话即撤销其绑定的刷新令牌
+type UserSession struct {
+	BaseModelUUID
+	UserID     uint64     `gorm:"index;not null" json:"user_id"` // 用户ID
+	UserAgent  string     `gorm:"size:255" json:"user_agent"`    // 客户端User-Agent
+	Device     string     `gorm:"size:100" json:"device"`        // 设备描述
+	IP         string     `gorm:"size:64" json:"ip"`             // 最近一次访问IP
+	LastSeenAt time.Time  `json:"last_seen_at"`                  // 最近活跃时间
+	ExpiresAt  time.Time  `gorm:"index" json:"expires_at"`       // 过期时间
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`          // 撤销时间
+}
+
+// TableName 表名
+func (UserSession) TableName() string {
+	return "star_user_sessions"
+}
+
+// IsActive 会话是否有效
+func (s *UserSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
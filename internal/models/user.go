@@ -3,9 +3,9 @@ package models
 
 import (
 	"errors"
+	"star-go/pkg/password"
+	"star-go/pkg/phonenumber"
 	"time"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 // 用户角色
@@ -18,17 +18,21 @@ const (
 
 // User 用户模型，继承基础模型
 type User struct {
-	BaseModel        // 继承基础模型
-	Username  string `gorm:"size:50;uniqueIndex;not null" json:"username"` // 用户名
-	Password  string `gorm:"size:100;not null" json:"-"` // 密码
-	Email     string `gorm:"size:100;uniqueIndex;not null" json:"email"` // 邮箱
-	Phone     string `gorm:"size:20" json:"phone"` // 电话
-	Nickname  string `gorm:"size:50" json:"nickname"` // 昵称
+	BaseModel         // 继承基础模型
+	Username  string  `gorm:"size:50;uniqueIndex;not null" json:"username"` // 用户名
+	Password  string  `gorm:"size:255;not null" json:"-"`                   // 密码哈希，argon2id参数较大时可能超过100个字符
+	Email     string  `gorm:"size:100;uniqueIndex;not null" json:"email"`   // 邮箱
+	Phone     *string `gorm:"size:20;uniqueIndex" json:"phone"`             // 电话，未绑定时为NULL以兼容唯一索引
+	Nickname  string  `gorm:"size:50" json:"nickname"`                      // 昵称
 	// Avatar 字段已移除
-	RoleID    uint       `gorm:"default:3" json:"role_id"`                // 角色ID，默认为普通用户
-	Role      *Role      `gorm:"foreignKey:RoleID" json:"role,omitempty"` // 角色关联
-	Status    int        `gorm:"default:1" json:"status"`                 // 状态：1正常 0禁用 -1删除
-	LastLogin *time.Time `json:"last_login"`                              // 最后登录时间
+	Roles     []*Role    `gorm:"many2many:star_user_roles" json:"roles,omitempty"` // 角色关联，一个用户可拥有多个角色
+	Status    int        `gorm:"default:1" json:"status"`                          // 状态：1正常 0禁用 -1删除
+	LastLogin *time.Time `json:"last_login"`                                       // 最后登录时间
+
+	TOTPSecret  string `gorm:"size:64" json:"-"`                  // TOTP密钥
+	TOTPEnabled bool   `gorm:"default:false" json:"totp_enabled"` // 是否开启两步验证
+
+	EmailVerified bool `gorm:"default:false" json:"email_verified"` // 邮箱是否已验证
 }
 
 // TableName 表名
@@ -36,25 +40,47 @@ func (User) TableName() string {
 	return "star_users"
 }
 
-// SetPassword 设置密码 - 使用bcrypt加密
-func (u *User) SetPassword(password string) error {
-	if len(password) == 0 {
+// SetPassword 设置密码 - 使用配置的哈希算法（默认argon2id）加密，不校验密码策略
+func (u *User) SetPassword(plain string) error {
+	if len(plain) == 0 {
 		return errors.New("密码不能为空")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := password.Hash(plain)
 	if err != nil {
 		return err
 	}
 
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	return nil
 }
 
-// CheckPassword 检查密码是否正确
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+// CheckPassword 检查密码是否正确，兼容bcrypt和argon2id哈希
+func (u *User) CheckPassword(plain string) bool {
+	return password.Verify(plain, u.Password)
+}
+
+// PasswordNeedsRehash 密码哈希的算法或参数是否已过时
+func (u *User) PasswordNeedsRehash() bool {
+	return password.NeedsRehash(u.Password)
+}
+
+// GetPhone 获取绑定的手机号，未绑定时返回空字符串
+func (u *User) GetPhone() string {
+	if u.Phone == nil {
+		return ""
+	}
+	return *u.Phone
+}
+
+// SetPhone 设置绑定的手机号，统一存储为E.164格式，传入空字符串表示解绑
+func (u *User) SetPhone(phone string) {
+	if phone == "" {
+		u.Phone = nil
+		return
+	}
+	normalized := phonenumber.Canonical(phone)
+	u.Phone = &normalized
 }
 
 // 检查用户是否活跃
@@ -64,18 +90,47 @@ func (u *User) IsActive() bool {
 
 // 检查用户是否是管理员
 func (u *User) IsAdmin() bool {
-	if u.Role != nil {
-		return u.Role.Code == RoleAdmin
+	return u.HasRole(RoleAdmin)
+}
+
+// HasRole 检查用户是否拥有指定编码的角色
+func (u *User) HasRole(code string) bool {
+	for _, role := range u.Roles {
+		if role != nil && role.Code == code {
+			return true
+		}
 	}
 	return false
 }
 
-// 检查用户是否有指定权限
+// RoleCodes 获取用户全部角色的编码
+func (u *User) RoleCodes() []string {
+	codes := make([]string, 0, len(u.Roles))
+	for _, role := range u.Roles {
+		if role != nil {
+			codes = append(codes, role.Code)
+		}
+	}
+	return codes
+}
+
+// 检查用户是否有指定权限，按所有角色有效权限的并集判断，任意角色的拒绝项都会生效
 func (u *User) HasPermission(permission string) bool {
-	if u.Role != nil {
-		return u.Role.HasPermission(permission)
+	return u.EffectivePermissions().HasPermission(permission)
+}
+
+// EffectivePermissions 获取用户所有角色（含继承）的有效权限并集
+func (u *User) EffectivePermissions() Permissions {
+	effective := Permissions{}
+	for _, role := range u.Roles {
+		if role == nil {
+			continue
+		}
+		for _, permission := range role.EffectivePermissions() {
+			effective.AddPermission(permission)
+		}
 	}
-	return false
+	return effective
 }
 
 // 更新最后登录时间
@@ -0,0 +1,75 @@
+// Package repository internal/repository/api_key_repository.go
+package repository
+
+import (
+	"star-go/internal/models"
+	"star-go/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IAPIKeyRepository API密钥仓库接口
+type IAPIKeyRepository interface {
+	Create(key *models.APIKey) error
+	FindByPrefix(prefix string) (*models.APIKey, error)
+	ListByUserID(userID uint64) ([]*models.APIKey, error)
+	CountByUserID(userID uint64) (int64, error)
+	Delete(userID, id uint64) (bool, error)
+	TouchLastUsed(id uint64, usedAt time.Time, ip string) error
+}
+
+// API密钥仓库实现
+type APIKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建API密钥仓库实例
+func NewAPIKeyRepository() IAPIKeyRepository {
+	return &APIKeyRepository{
+		db: database.GetDB(),
+	}
+}
+
+// 创建API密钥
+func (r *APIKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// 根据前缀查找API密钥
+func (r *APIKeyRepository) FindByPrefix(prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// 获取用户的全部API密钥，按创建时间倒序
+func (r *APIKeyRepository) ListByUserID(userID uint64) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// 统计用户的API密钥数量
+func (r *APIKeyRepository) CountByUserID(userID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// 吊销用户的API密钥
+func (r *APIKeyRepository) Delete(userID, id uint64) (bool, error) {
+	result := r.db.Where("id = ? AND user_id = ?", id, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// 更新最近使用时间和IP，不修改更新时间
+func (r *APIKeyRepository) TouchLastUsed(id uint64, usedAt time.Time, ip string) error {
+	return r.db.Model(&models.APIKey{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"last_used_at": usedAt, "last_used_ip": ip}).Error
+}
@@ -0,0 +1,75 @@
+// Package repository internal/repository/identity_repository.go
+package repository
+
+import (
+	"star-go/internal/models"
+	"star-go/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// IIdentityRepository 外部身份仓库接口
+type IIdentityRepository interface {
+	Create(identity *models.UserIdentity) error
+	Update(identity *models.UserIdentity) error
+	FindByProviderSubject(provider, subject string) (*models.UserIdentity, error)
+	FindByUserProvider(userID uint64, provider string) (*models.UserIdentity, error)
+	ListByUserID(userID uint64) ([]*models.UserIdentity, error)
+	Delete(userID uint64, provider string) (bool, error)
+}
+
+// 外部身份仓库实现
+type IdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewIdentityRepository 创建外部身份仓库实例
+func NewIdentityRepository() IIdentityRepository {
+	return &IdentityRepository{
+		db: database.GetDB(),
+	}
+}
+
+// 创建外部身份
+func (r *IdentityRepository) Create(identity *models.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// 更新外部身份
+func (r *IdentityRepository) Update(identity *models.UserIdentity) error {
+	return r.db.Save(identity).Error
+}
+
+// 根据提供方和外部账户标识查找
+func (r *IdentityRepository) FindByProviderSubject(provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// 查找用户在指定提供方关联的外部身份
+func (r *IdentityRepository) FindByUserProvider(userID uint64, provider string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("user_id = ? AND provider = ?", userID, provider).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// 获取用户关联的全部外部身份
+func (r *IdentityRepository) ListByUserID(userID uint64) ([]*models.UserIdentity, error) {
+	var identities []*models.UserIdentity
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+// 解除用户在指定提供方的关联，物理删除以便外部账户重新关联
+func (r *IdentityRepository) Delete(userID uint64, provider string) (bool, error) {
+	result := r.db.Unscoped().Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.UserIdentity{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
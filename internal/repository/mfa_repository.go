@@ -0,0 +1,69 @@
+// Package repository internal/repository/mfa_repository.go
+package repository
+
+import (
+	"star-go/internal/models"
+	"star-go/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// IRecoveryCodeRepository 恢复码仓库接口
+type IRecoveryCodeRepository interface {
+	Replace(userID uint64, hashes []string) error
+	Consume(userID uint64, hash string) (bool, error)
+	CountUnused(userID uint64) (int64, error)
+	DeleteByUserID(userID uint64) error
+}
+
+// 恢复码仓库实现
+type RecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository 创建恢复码仓库实例
+func NewRecoveryCodeRepository() IRecoveryCodeRepository {
+	return &RecoveryCodeRepository{
+		db: database.GetDB(),
+	}
+}
+
+// 替换用户的全部恢复码
+func (r *RecoveryCodeRepository) Replace(userID uint64, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]*models.UserRecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, &models.UserRecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// 使用恢复码，通过条件更新保证并发时只能成功一次
+func (r *RecoveryCodeRepository) Consume(userID uint64, hash string) (bool, error) {
+	result := r.db.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// 统计未使用的恢复码数量
+func (r *RecoveryCodeRepository) CountUnused(userID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// 删除用户的全部恢复码
+func (r *RecoveryCodeRepository) DeleteByUserID(userID uint64) error {
+	return r.db.Unscoped().Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error
+}
@@ -0,0 +1,61 @@
+// Package repository internal/repository/password_history_repository.go
+package repository
+
+import (
+	"star-go/internal/models"
+	"star-go/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// IPasswordHistoryRepository 历史密码仓库接口
+type IPasswordHistoryRepository interface {
+	ListRecent(userID uint64, limit int) ([]string, error)
+	Add(userID uint64, passwordHash string, keep int) error
+}
+
+// 历史密码仓库实现
+type PasswordHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordHistoryRepository 创建历史密码仓库实例
+func NewPasswordHistoryRepository() IPasswordHistoryRepository {
+	return &PasswordHistoryRepository{
+		db: database.GetDB(),
+	}
+}
+
+// 查询最近使用过的密码哈希
+func (r *PasswordHistoryRepository) ListRecent(userID uint64, limit int) ([]string, error) {
+	var hashes []string
+	err := r.db.Model(&models.UserPasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit).
+		Pluck("password_hash", &hashes).Error
+	return hashes, err
+}
+
+// 记录历史密码，并只保留最近keep条
+func (r *PasswordHistoryRepository) Add(userID uint64, passwordHash string, keep int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		history := &models.UserPasswordHistory{UserID: userID, PasswordHash: passwordHash}
+		if err := tx.Create(history).Error; err != nil {
+			return err
+		}
+
+		// 清理超出保留数量的记录
+		var keepIDs []uint64
+		if err := tx.Model(&models.UserPasswordHistory{}).
+			Where("user_id = ?", userID).
+			Order("id DESC").
+			Limit(keep).
+			Pluck("id", &keepIDs).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("user_id = ? AND id NOT IN ?", userID, keepIDs).
+			Delete(&models.UserPasswordHistory{}).Error
+	})
+}
@@ -0,0 +1,190 @@
+// Package repository internal/repository/role_repository.go
+package repository
+
+import (
+	"star-go/internal/models"
+	"star-go/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+// 用户角色关联表
+const userRolesTable = "star_user_roles"
+
+// IRoleRepository 角色仓库接口
+type IRoleRepository interface {
+	Create(role *models.Role) error
+	Update(role *models.Role) error
+	Delete(id uint64) error
+	FindByID(id uint64) (*models.Role, error)
+	FindByCode(code string) (*models.Role, error)
+	FindByName(name string) (*models.Role, error)
+	FindByIDs(ids []uint64) ([]*models.Role, error)
+	List(page, size int, query string) ([]*models.Role, int64, error)
+	CountUsers(roleID uint64) (int64, error)
+	CountChildren(roleID uint64) (int64, error)
+}
+
+// 角色仓库实现
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository 创建角色仓库实例
+func NewRoleRepository() IRoleRepository {
+	return &RoleRepository{
+		db: database.GetDB(),
+	}
+}
+
+// 创建角色，父角色只通过 ParentID 关联
+func (r *RoleRepository) Create(role *models.Role) error {
+	return r.db.Omit("Parent").Create(role).Error
+}
+
+// 更新角色，父角色只通过 ParentID 关联
+func (r *RoleRepository) Update(role *models.Role) error {
+	return r.db.Omit("Parent").Save(role).Error
+}
+
+// 删除角色及其用户关联，物理删除以释放名称和编码的唯一索引
+func (r *RoleRepository) Delete(id uint64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM "+userRolesTable+" WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.Role{}, id).Error
+	})
+}
+
+// 根据ID查找角色，同时加载全部祖先角色
+func (r *RoleRepository) FindByID(id uint64) (*models.Role, error) {
+	var role models.Role
+	err := r.db.First(&role, id).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := loadRoleAncestors(r.db, []*models.Role{&role}); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// 根据编码查找角色，同时加载全部祖先角色
+func (r *RoleRepository) FindByCode(code string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Where("code = ?", code).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := loadRoleAncestors(r.db, []*models.Role{&role}); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// 根据名称查找角色
+func (r *RoleRepository) FindByName(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// 根据ID批量查找角色
+func (r *RoleRepository) FindByIDs(ids []uint64) ([]*models.Role, error) {
+	var roles []*models.Role
+	if len(ids) == 0 {
+		return roles, nil
+	}
+	err := r.db.Where("id IN ?", ids).Order("id").Find(&roles).Error
+	return roles, err
+}
+
+// 查询角色列表
+func (r *RoleRepository) List(page, size int, query string) ([]*models.Role, int64, error) {
+	var roles []*models.Role
+	var total int64
+
+	db := r.db.Model(&models.Role{})
+
+	// 如果有查询条件，添加查询
+	if query != "" {
+		db = db.Where("name LIKE ? OR code LIKE ?", "%"+query+"%", "%"+query+"%")
+	}
+
+	// 计算总数
+	err := db.Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	offset := (page - 1) * size
+	err = db.Order("id").Offset(offset).Limit(size).Find(&roles).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return roles, total, nil
+}
+
+// 统计使用该角色的用户数量，不含已删除的用户
+func (r *RoleRepository) CountUsers(roleID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).
+		Joins("JOIN "+userRolesTable+" ON "+userRolesTable+".user_id = star_users.id").
+		Where(userRolesTable+".role_id = ?", roleID).
+		Count(&count).Error
+	return count, err
+}
+
+// 统计直接继承该角色的子角色数量
+func (r *RoleRepository) CountChildren(roleID uint64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Role{}).Where("parent_id = ?", roleID).Count(&count).Error
+	return count, err
+}
+
+// 逐级加载角色的父角色，每一层只查询一次数据库，已加载的角色不会重复查询
+func loadRoleAncestors(db *gorm.DB, roles []*models.Role) error {
+	loaded := make(map[uint64]*models.Role, len(roles))
+	for _, role := range roles {
+		if _, ok := loaded[role.ID]; !ok {
+			loaded[role.ID] = role
+		}
+	}
+
+	pending := roles
+	for len(pending) > 0 {
+		var missing []uint64
+		for _, role := range pending {
+			if role.ParentID == nil {
+				continue
+			}
+			if _, ok := loaded[*role.ParentID]; !ok {
+				missing = append(missing, *role.ParentID)
+			}
+		}
+
+		var parents []*models.Role
+		if len(missing) > 0 {
+			if err := db.Where("id IN ?", missing).Find(&parents).Error; err != nil {
+				return err
+			}
+		}
+		for _, parent := range parents {
+			loaded[parent.ID] = parent
+		}
+
+		for _, role := range pending {
+			if role.ParentID != nil {
+				role.Parent = loaded[*role.ParentID]
+			}
+		}
+		pending = parents
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Package repository internal/repository/session_repository.go
+package repository
+
+import (
+	"star-go/internal/models"
+	"star-go/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ISessionRepository 会话仓库接口
+type ISessionRepository interface {
+	Create(session *models.UserSession) error
+	Update(session *models.UserSession) error
+	FindByID(id string) (*models.UserSession, error)
+	ListActiveByUserID(userID uint64) ([]*models.UserSession, error)
+	RevokeAllByUserID(userID uint64) ([]string, error)
+}
+
+// 会话仓库实现
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建会话仓库实例
+func NewSessionRepository() ISessionRepository {
+	return &SessionRepository{
+		db: database.GetDB(),
+	}
+}
+
+// 创建会话
+func (r *SessionRepository) Create(session *models.UserSession) error {
+	return r.db.Create(session).Error
+}
+
+// 更新会话
+func (r *SessionRepository) Update(session *models.UserSession) error {
+	return r.db.Save(session).Error
+}
+
+// 根据ID查找会话
+func (r *SessionRepository) FindByID(id string) (*models.UserSession, error) {
+	var session models.UserSession
+	err := r.db.Where("id = ?", id).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// 查询用户的有效会话，按最近活跃时间倒序
+func (r *SessionRepository) ListActiveByUserID(userID uint64) ([]*models.UserSession, error) {
+	var sessions []*models.UserSession
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// 撤销用户的所有有效会话，返回被撤销的会话ID
+func (r *SessionRepository) RevokeAllByUserID(userID uint64) ([]string, error) {
+	var ids []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserSession{}).
+			Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return tx.Model(&models.UserSession{}).
+			Where("id IN ?", ids).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
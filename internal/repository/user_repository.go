@@ -4,6 +4,7 @@ package repository
 import (
 	"star-go/internal/models"
 	"star-go/pkg/database"
+	"star-go/pkg/phonenumber"
 
 	"gorm.io/gorm"
 )
@@ -16,7 +17,10 @@ type IUserRepository interface {
 	FindByID(id uint64) (*models.User, error)
 	FindByUsername(username string) (*models.User, error)
 	FindByEmail(email string) (*models.User, error)
+	FindByPhone(phone string) (*models.User, error)
 	List(page, size int, query string) ([]*models.User, int64, error)
+	ReplaceRoles(user *models.User, roles []*models.Role) error
+	UpdateWithRoles(user *models.User, roles []*models.Role) error
 }
 
 // 用户仓库实现
@@ -36,9 +40,9 @@ func (r *UserRepository) Create(user *models.User) error {
 	return r.db.Create(user).Error
 }
 
-// 更新用户
+// 更新用户，角色关联通过 ReplaceRoles 单独维护
 func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+	return r.db.Omit("Roles").Save(user).Error
 }
 
 // 删除用户
@@ -49,30 +53,52 @@ func (r *UserRepository) Delete(id uint64) error {
 // 根据ID查找用户
 func (r *UserRepository) FindByID(id uint64) (*models.User, error) {
 	var user models.User
-	err := r.db.Preload("Role").First(&user, id).Error
+	err := r.db.Preload("Roles").First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadRoleAncestors(&user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
 // 根据用户名查找用户
 func (r *UserRepository) FindByUsername(username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Preload("Role").Where("username = ?", username).First(&user).Error
+	err := r.db.Preload("Roles").Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadRoleAncestors(&user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
 // 根据邮箱查找用户
 func (r *UserRepository) FindByEmail(email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Preload("Role").Where("email = ?", email).First(&user).Error
+	err := r.db.Preload("Roles").Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := r.loadRoleAncestors(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// 根据手机号查找用户
+func (r *UserRepository) FindByPhone(phone string) (*models.User, error) {
+	var user models.User
+	err := r.db.Preload("Roles").Where("phone = ?", phonenumber.Canonical(phone)).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadRoleAncestors(&user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
@@ -81,7 +107,7 @@ func (r *UserRepository) List(page, size int, query string) ([]*models.User, int
 	var users []*models.User
 	var total int64
 
-	db := r.db.Model(&models.User{}).Preload("Role")
+	db := r.db.Model(&models.User{}).Preload("Roles")
 
 	// 如果有查询条件，添加查询
 	if query != "" {
@@ -101,6 +127,39 @@ func (r *UserRepository) List(page, size int, query string) ([]*models.User, int
 	if err != nil {
 		return nil, 0, err
 	}
+	if err := r.loadRoleAncestors(users...); err != nil {
+		return nil, 0, err
+	}
 
 	return users, total, nil
 }
+
+// 替换用户的全部角色
+func (r *UserRepository) ReplaceRoles(user *models.User, roles []*models.Role) error {
+	if len(roles) == 0 {
+		return r.db.Model(user).Association("Roles").Clear()
+	}
+	return r.db.Model(user).Association("Roles").Replace(roles)
+}
+
+// 在同一事务中更新用户信息并替换全部角色
+func (r *UserRepository) UpdateWithRoles(user *models.User, roles []*models.Role) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Roles").Save(user).Error; err != nil {
+			return err
+		}
+		if len(roles) == 0 {
+			return tx.Model(user).Association("Roles").Clear()
+		}
+		return tx.Model(user).Association("Roles").Replace(roles)
+	})
+}
+
+// 加载用户角色的祖先角色，用于计算继承的权限
+func (r *UserRepository) loadRoleAncestors(users ...*models.User) error {
+	var roles []*models.Role
+	for _, user := range users {
+		roles = append(roles, user.Roles...)
+	}
+	return loadRoleAncestors(r.db, roles)
+}
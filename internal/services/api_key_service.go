@@ -0,0 +1,174 @@
+// Package services internal/services/api_key_service.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/logger"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix          = "sk_"       // 密钥前缀标识，便于在日志和代码仓库中识别泄露的密钥
+	apiKeyMaxPerUser      = 20          // 每个用户最多可创建的密钥数量
+	apiKeyTouchInterval   = time.Minute // 最近使用时间的更新间隔，避免每次请求都写数据库
+	apiKeyPrefixRandBytes = 6
+	apiKeySecretRandBytes = 24
+)
+
+// ErrInvalidAPIKey API密钥无效、已吊销或已过期
+var ErrInvalidAPIKey = errors.New("API密钥无效或已过期")
+
+// IAPIKeyService API密钥服务接口
+type IAPIKeyService interface {
+	Create(userID uint64, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error)
+	List(userID uint64) ([]*models.APIKey, error)
+	Revoke(userID, id uint64) error
+	Authenticate(ctx context.Context, rawKey, clientIP string) (*models.APIKey, *models.User, error)
+}
+
+// APIKeyService API密钥服务实现
+type APIKeyService struct {
+	apiKeyRepo repository.IAPIKeyRepository
+	userRepo   repository.IUserRepository
+}
+
+// NewAPIKeyService 创建API密钥服务实例
+func NewAPIKeyService() IAPIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: repository.NewAPIKeyRepository(),
+		userRepo:   repository.NewUserRepository(),
+	}
+}
+
+// Create 创建API密钥，授权范围必须是用户当前权限的子集，返回的明文密钥只出现这一次
+func (s *APIKeyService) Create(userID uint64, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 校验授权范围
+	var granted models.Permissions
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		if err := models.ValidatePermission(scope); err != nil {
+			return nil, "", err
+		}
+		// 拒绝项只会收窄授权范围，无需检查用户是否拥有
+		if !strings.HasPrefix(scope, models.PermDenyPrefix) && !user.HasPermission(scope) {
+			return nil, "", fmt.Errorf("无权授予 %s 权限", scope)
+		}
+		granted.AddPermission(scope)
+	}
+	if len(granted) == 0 {
+		return nil, "", errors.New("至少需要指定一个授权范围")
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", errors.New("过期时间必须晚于当前时间")
+	}
+
+	count, err := s.apiKeyRepo.CountByUserID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= apiKeyMaxPerUser {
+		return nil, "", fmt.Errorf("最多只能创建%d个API密钥", apiKeyMaxPerUser)
+	}
+
+	// 密钥格式: sk_<前缀随机部分>_<密钥随机部分>
+	prefix := apiKeyPrefix + randomHex(apiKeyPrefixRandBytes)
+	rawKey := prefix + "_" + randomHex(apiKeySecretRandBytes)
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    granted,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, "", err
+	}
+	return key, rawKey, nil
+}
+
+// List 获取用户的API密钥列表
+func (s *APIKeyService) List(userID uint64) ([]*models.APIKey, error) {
+	return s.apiKeyRepo.ListByUserID(userID)
+}
+
+// Revoke 吊销用户的API密钥
+func (s *APIKeyService) Revoke(userID, id uint64) error {
+	deleted, err := s.apiKeyRepo.Delete(userID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("API密钥不存在")
+	}
+	return nil
+}
+
+// Authenticate 校验API密钥并返回密钥和所属用户
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey, clientIP string) (*models.APIKey, *models.User, error) {
+	index := strings.LastIndex(rawKey, "_")
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) || index <= len(apiKeyPrefix) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.FindByPrefix(rawKey[:index])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashAPIKey(rawKey))) != 1 || key.IsExpired() {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := s.userRepo.FindByID(key.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidAPIKey
+		}
+		return nil, nil, err
+	}
+	if !user.IsActive() {
+		return nil, nil, errors.New("用户已被禁用")
+	}
+
+	// 按间隔更新最近使用信息，失败不影响本次请求
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval || key.LastUsedIP != clientIP {
+		if err := s.apiKeyRepo.TouchLastUsed(key.ID, now, clientIP); err != nil {
+			logger.GetLogger().Warn("更新API密钥使用时间失败", zap.Uint64("api_key_id", key.ID), zap.Error(err))
+		}
+		key.LastUsedAt = &now
+		key.LastUsedIP = clientIP
+	}
+
+	return key, user, nil
+}
+
+// 计算API密钥哈希，密钥本身为高熵随机串，使用SHA-256即可
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,32 +2,73 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"star-go/internal/models"
 	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/logger"
+	"star-go/pkg/phonenumber"
 	"star-go/pkg/utils"
+	"sync"
+	"time"
 
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 // IAuthService 认证服务接口
 type IAuthService interface {
 	Register(username, password, email, nickname string) (*models.User, error)
-	Login(username, password string) (string, string, *models.User, error)
-	RefreshToken(refreshToken string) (string, error)
+	Login(ctx context.Context, username, password string, client *ClientInfo) (*LoginResult, error)
+	LoginMFA(ctx context.Context, challenge, code string, client *ClientInfo) (string, string, *models.User, error)
+	LoginBySMS(ctx context.Context, biz, phone, code string, client *ClientInfo) (*LoginResult, error)
+	LoginExternal(ctx context.Context, user *models.User, client *ClientInfo) (*LoginResult, error)
+	RefreshToken(ctx context.Context, refreshToken string, client *ClientInfo) (string, string, error)
 	VerifyToken(token string) (*models.User, error)
 	ChangePassword(userID uint64, oldPassword, newPassword string) error
+	Logout(ctx context.Context, claims *utils.JWTClaims) error
+	LogoutAll(ctx context.Context, userID uint64) error
+	IsTokenRevoked(ctx context.Context, claims *utils.JWTClaims) (bool, error)
+}
+
+// LoginResult 密码登录结果，开启两步验证时仅返回挑战令牌
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+	User         *models.User
+	MFAToken     string // 非空表示需要调用两步验证登录接口完成登录
 }
 
 // AuthService 认证服务实现
 type AuthService struct {
-	userRepo repository.IUserRepository
+	userRepo        repository.IUserRepository
+	roleRepo        repository.IRoleRepository
+	sessionService  ISessionService
+	smsService      SMSService
+	mfaService      IMFAService
+	loginGuard      LoginGuard
+	passwordService IPasswordService
+	emailVerify     IEmailVerifyService
+	tokenStore      cache.RefreshTokenStore
+	denylist        cache.TokenDenylist
 }
 
 // NewAuthService 创建认证服务实例
 func NewAuthService() IAuthService {
 	return &AuthService{
-		userRepo: repository.NewUserRepository(),
+		userRepo:        repository.NewUserRepository(),
+		roleRepo:        repository.NewRoleRepository(),
+		sessionService:  NewSessionService(),
+		smsService:      NewSMSService(),
+		mfaService:      NewMFAService(),
+		loginGuard:      NewLoginGuard(),
+		passwordService: NewPasswordService(),
+		emailVerify:     NewEmailVerifyService(),
+		tokenStore:      cache.NewRefreshTokenStore(cache.GetCache(), utils.RefreshTokenTTL()),
+		denylist:        cache.NewTokenDenylist(cache.GetCache(), utils.RefreshTokenTTL()),
 	}
 }
 
@@ -45,17 +86,25 @@ func (s *AuthService) Register(username, password, email, nickname string) (*mod
 		return nil, errors.New("邮箱已存在")
 	}
 
+	// 新用户默认为普通用户角色
+	roles, err := defaultUserRoles(s.roleRepo)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建用户
 	user := &models.User{
 		Username: username,
 		Email:    email,
 		Nickname: nickname,
-		RoleID:   2, // 默认为普通用户角色
+		Roles:    roles,
 		Status:   models.StatusActive,
 	}
+	// 开启邮箱验证时新用户需验证邮箱后才能登录
+	user.EmailVerified = !s.emailVerify.Enabled()
 
-	// 设置密码
-	if err := user.SetPassword(password); err != nil {
+	// 按密码策略设置密码
+	if err := s.passwordService.SetPassword(user, password); err != nil {
 		return nil, err
 	}
 
@@ -64,44 +113,249 @@ func (s *AuthService) Register(username, password, email, nickname string) (*mod
 		return nil, err
 	}
 
+	// 发送验证邮件，失败时用户可通过重发接口重新获取
+	if !user.EmailVerified {
+		if err := s.emailVerify.Send(context.Background(), user); err != nil {
+			logger.GetLogger().Error("发送验证邮件失败", zap.Uint64("user_id", user.ID), zap.Error(err))
+		}
+	}
+
 	return user, nil
 }
 
-// Login 用户登录
-func (s *AuthService) Login(username, password string) (string, string, *models.User, error) {
-	// 查找用户
+// 用户不存在时参与密码比对的哈希，使用当前配置的算法生成，使两种失败的响应耗时一致
+var dummyUser = sync.OnceValue(func() *models.User {
+	user := &models.User{}
+	_ = user.SetPassword(randomHex(16))
+	return user
+})
+
+// Login 用户登录，开启两步验证的用户在密码验证通过后返回挑战令牌
+// 用户不存在和密码错误统一返回 ErrInvalidCredentials，失败过多时返回 *LoginLockedError
+func (s *AuthService) Login(ctx context.Context, username, password string, client *ClientInfo) (*LoginResult, error) {
+	var clientIP string
+	if client != nil {
+		clientIP = client.IP
+	}
+
+	// 检查用户名或IP是否已被锁定
+	if err := s.loginGuard.Check(ctx, username, clientIP); err != nil {
+		return nil, err
+	}
+
+	// 查找用户，不存在时同样进行一次密码比对
 	user, err := s.userRepo.FindByUsername(username)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if user == nil {
+		dummyUser().CheckPassword(password)
+		return nil, s.loginFailed(ctx, username, clientIP)
+	}
+
+	// 验证密码
+	if !user.CheckPassword(password) {
+		return nil, s.loginFailed(ctx, username, clientIP)
+	}
+
+	// 旧算法或旧参数的密码哈希在登录成功后透明升级
+	if s.passwordService.RehashIfNeeded(user, password) {
+		if err := s.userRepo.Update(user); err != nil {
+			logger.GetLogger().Warn("保存升级后的密码哈希失败", zap.Uint64("user_id", user.ID), zap.Error(err))
+		}
+	}
+
+	// 密码正确后才提示账户状态，避免泄露账户是否存在
+	if !user.IsActive() {
+		return nil, errors.New("用户已被禁用")
+	}
+
+	// 开启邮箱验证时，未验证的账户不能使用密码登录
+	if s.emailVerify.Enabled() && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	result, err := s.loginOrChallenge(ctx, user, client)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", "", nil, errors.New("用户不存在")
+		return nil, err
+	}
+
+	// 两步验证通过前保留失败记录，避免凭正确的密码反复获取新挑战来暴力破解验证码
+	if result.MFAToken == "" {
+		_ = s.loginGuard.Reset(ctx, username)
+	}
+	return result, nil
+}
+
+// 记录登录失败并返回统一的错误
+func (s *AuthService) loginFailed(ctx context.Context, username, clientIP string) error {
+	if err := s.loginGuard.RecordFailure(ctx, username, clientIP); err != nil {
+		logger.GetLogger().Error("记录登录失败次数失败", zap.Error(err))
+	}
+	return ErrInvalidCredentials
+}
+
+// LoginMFA 使用登录挑战令牌和两步验证码（或恢复码）完成登录
+// 验证码错误计入用户的登录失败次数，与密码错误共用锁定策略
+func (s *AuthService) LoginMFA(ctx context.Context, challenge, code string, client *ClientInfo) (string, string, *models.User, error) {
+	var clientIP string
+	if client != nil {
+		clientIP = client.IP
+	}
+
+	user, err := s.mfaService.ChallengeUser(ctx, challenge)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// 检查用户名或IP是否已被锁定
+	if err := s.loginGuard.Check(ctx, user.Username, clientIP); err != nil {
+		return "", "", nil, err
+	}
+
+	if _, err := s.mfaService.VerifyChallenge(ctx, challenge, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) || errors.Is(err, ErrMFATooManyAttempts) {
+			if recordErr := s.loginGuard.RecordFailure(ctx, user.Username, clientIP); recordErr != nil {
+				logger.GetLogger().Error("记录登录失败次数失败", zap.Error(recordErr))
+			}
 		}
 		return "", "", nil, err
 	}
+	_ = s.loginGuard.Reset(ctx, user.Username)
 
-	// 检查用户状态
+	// 挑战期间账户可能被禁用
 	if !user.IsActive() {
 		return "", "", nil, errors.New("用户已被禁用")
 	}
 
-	// 验证密码
-	if !user.CheckPassword(password) {
-		return "", "", nil, errors.New("密码错误")
+	return s.completeLogin(ctx, user, client)
+}
+
+// LoginBySMS 手机号验证码登录，业务类型为注册时自动为未注册的手机号创建账户，开启两步验证的用户返回挑战令牌
+func (s *AuthService) LoginBySMS(ctx context.Context, biz, phone, code string, client *ClientInfo) (*LoginResult, error) {
+	if biz != BizLogin && biz != BizRegister {
+		return nil, errors.New("无效的业务类型")
+	}
+
+	// 统一为E.164格式，保证查找和注册使用相同的号码
+	phone, err := phonenumber.Normalize(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	// 校验验证码
+	ok, err := s.smsService.Verify(ctx, biz, phone, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("验证码错误")
+	}
+
+	// 查找手机号对应的用户
+	user, err := s.userRepo.FindByPhone(phone)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		if biz != BizRegister {
+			return nil, errors.New("该手机号未注册")
+		}
+
+		// 自动注册
+		user, err = s.registerByPhone(phone)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 检查用户状态
+	if !user.IsActive() {
+		return nil, errors.New("用户已被禁用")
+	}
+
+	// 短信验证码只是第一因素，开启两步验证时同样需要完成挑战
+	return s.loginOrChallenge(ctx, user, client)
+}
+
+// 使用手机号创建账户，用户名和密码随机生成，之后可通过找回密码设置
+func (s *AuthService) registerByPhone(phone string) (*models.User, error) {
+	// 生成不重复的用户名
+	username, err := generateUsername(s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := defaultUserRoles(s.roleRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 邮箱为必填唯一字段，手机号注册的用户使用占位邮箱
+	user := &models.User{
+		Username: username,
+		Email:    username + "@phone.star-go.local",
+		Nickname: "用户" + phone[len(phone)-4:],
+		Roles:    roles,
+		Status:   models.StatusActive,
+	}
+	user.SetPhone(phone)
+
+	// 设置随机密码
+	if err := user.SetPassword(randomHex(16)); err != nil {
+		return nil, err
+	}
+
+	// 保存用户
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	// 重新加载以获取角色信息
+	return s.userRepo.FindByID(user.ID)
+}
+
+// LoginExternal 外部身份（如OIDC）认证通过后登录，开启两步验证的用户同样返回挑战令牌
+func (s *AuthService) LoginExternal(ctx context.Context, user *models.User, client *ClientInfo) (*LoginResult, error) {
+	if !user.IsActive() {
+		return nil, errors.New("用户已被禁用")
+	}
+
+	return s.loginOrChallenge(ctx, user, client)
+}
+
+// 第一因素验证通过后，开启两步验证的用户返回挑战令牌，否则直接完成登录
+func (s *AuthService) loginOrChallenge(ctx context.Context, user *models.User, client *ClientInfo) (*LoginResult, error) {
+	if user.TOTPEnabled {
+		challenge, err := s.mfaService.CreateChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, MFAToken: challenge}, nil
 	}
 
+	accessToken, refreshToken, user, err := s.completeLogin(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken, User: user}, nil
+}
+
+// 完成登录：更新最后登录时间，创建会话并签发令牌
+func (s *AuthService) completeLogin(ctx context.Context, user *models.User, client *ClientInfo) (string, string, *models.User, error) {
 	// 更新最后登录时间
 	user.UpdateLastLogin()
 	if err := s.userRepo.Update(user); err != nil {
 		return "", "", nil, err
 	}
 
-	// 生成访问令牌
-	accessToken, err := utils.GenerateAccessToken(user.ID)
+	// 每次登录创建一个设备会话，会话ID即刷新令牌族ID
+	session, err := s.sessionService.CreateSession(ctx, user.ID, client)
 	if err != nil {
 		return "", "", nil, err
 	}
 
-	// 生成刷新令牌
-	refreshToken, err := utils.GenerateRefreshToken(user.ID)
+	accessToken, refreshToken, err := s.issueTokens(ctx, user.ID, session.ID)
 	if err != nil {
 		return "", "", nil, err
 	}
@@ -109,32 +363,104 @@ func (s *AuthService) Login(username, password string) (string, string, *models.
 	return accessToken, refreshToken, user, nil
 }
 
-// RefreshToken 刷新令牌
-func (s *AuthService) RefreshToken(refreshToken string) (string, error) {
+// RefreshToken 刷新令牌 - 每次刷新都会轮换刷新令牌，旧令牌被重放时撤销整个令牌族
+func (s *AuthService) RefreshToken(ctx context.Context, refreshToken string, client *ClientInfo) (string, string, error) {
 	// 验证刷新令牌
 	claims, err := utils.ParseRefreshToken(refreshToken)
 	if err != nil {
-		return "", errors.New("无效的刷新令牌")
+		return "", "", errors.New("无效的刷新令牌")
+	}
+
+	// 查找服务端记录
+	record, err := s.tokenStore.Get(ctx, refreshToken)
+	if err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return "", "", errors.New("无效的刷新令牌")
+		}
+		return "", "", err
+	}
+	if record.UserID != claims.UserID {
+		return "", "", errors.New("无效的刷新令牌")
+	}
+
+	// 检查令牌族是否已被撤销
+	revoked, err := s.tokenStore.IsFamilyRevoked(ctx, record.FamilyID)
+	if err != nil {
+		return "", "", err
+	}
+	if !revoked {
+		// 检查是否在"退出所有设备"之前签发
+		revoked, err = s.IsTokenRevoked(ctx, claims)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	if revoked {
+		return "", "", errors.New("刷新令牌已被撤销")
 	}
 
 	// 检查用户是否存在
 	user, err := s.userRepo.FindByID(claims.UserID)
 	if err != nil {
-		return "", errors.New("用户不存在")
+		return "", "", errors.New("用户不存在")
 	}
 
 	// 检查用户状态
 	if !user.IsActive() {
-		return "", errors.New("用户已被禁用")
+		return "", "", errors.New("用户已被禁用")
 	}
 
-	// 生成新的访问令牌
-	accessToken, err := utils.GenerateAccessToken(user.ID)
+	// 更新会话活跃信息，会话已被撤销时拒绝刷新
+	if err := s.sessionService.TouchSession(ctx, record.FamilyID, client); err != nil {
+		return "", "", err
+	}
+
+	// 签发新令牌前原子地占用旧令牌，并发刷新时只有一个请求能成功；
+	// 已使用过的令牌再次出现，说明令牌可能已泄露，撤销整个令牌族
+	claimed, err := s.tokenStore.Claim(ctx, refreshToken, record)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	if !claimed {
+		// 撤销令牌族对应的会话，同时使该会话下已签发的访问令牌失效
+		if err := s.sessionService.RevokeSession(ctx, record.UserID, record.FamilyID); err != nil {
+			return "", "", err
+		}
+		logger.GetLogger().Warn("检测到刷新令牌重复使用，已撤销令牌族和会话",
+			zap.Uint64("user_id", record.UserID),
+			zap.String("family_id", record.FamilyID))
+		return "", "", errors.New("刷新令牌已失效，请重新登录")
 	}
 
-	return accessToken, nil
+	// 在同一令牌族中签发新的令牌对
+	return s.issueTokens(ctx, user.ID, record.FamilyID)
+}
+
+// 签发访问令牌和刷新令牌，并保存刷新令牌记录
+func (s *AuthService) issueTokens(ctx context.Context, userID uint64, sessionID string) (string, string, error) {
+	// 生成访问令牌，绑定会话以便撤销会话时同时失效
+	accessToken, err := utils.GenerateAccessToken(userID, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 生成刷新令牌
+	refreshToken, err := utils.GenerateRefreshToken(userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	// 保存刷新令牌记录
+	record := &cache.RefreshTokenRecord{
+		UserID:    userID,
+		FamilyID:  sessionID,
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+	}
+	if err := s.tokenStore.Save(ctx, refreshToken, record); err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
 }
 
 // 验证令牌
@@ -172,11 +498,96 @@ func (s *AuthService) ChangePassword(userID uint64, oldPassword, newPassword str
 		return errors.New("原密码错误")
 	}
 
-	// 设置新密码
-	if err := user.SetPassword(newPassword); err != nil {
+	// 按密码策略设置新密码
+	if err := s.passwordService.SetPassword(user, newPassword); err != nil {
 		return err
 	}
 
 	// 更新用户
 	return s.userRepo.Update(user)
 }
+
+// Logout 退出登录 - 撤销当前访问令牌及其所属会话
+func (s *AuthService) Logout(ctx context.Context, claims *utils.JWTClaims) error {
+	// 将访问令牌加入黑名单直至其过期
+	var ttl time.Duration
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+	if err := s.denylist.Revoke(ctx, claims.ID, ttl); err != nil {
+		return err
+	}
+
+	// 撤销会话及其绑定的刷新令牌
+	if claims.SessionID == "" {
+		return nil
+	}
+	return s.sessionService.RevokeSession(ctx, claims.UserID, claims.SessionID)
+}
+
+// LogoutAll 退出所有设备 - 撤销该用户的所有会话以及此刻之前签发的所有令牌
+func (s *AuthService) LogoutAll(ctx context.Context, userID uint64) error {
+	if err := s.sessionService.RevokeAllSessions(ctx, userID); err != nil {
+		return err
+	}
+	return s.denylist.RevokeUserBefore(ctx, userID, time.Now())
+}
+
+// IsTokenRevoked 检查令牌是否已被撤销
+func (s *AuthService) IsTokenRevoked(ctx context.Context, claims *utils.JWTClaims) (bool, error) {
+	// 检查jti黑名单
+	revoked, err := s.denylist.IsRevoked(ctx, claims.ID)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	// 检查所属会话是否已被撤销
+	revoked, err = s.denylist.IsSessionRevoked(ctx, claims.SessionID)
+	if err != nil || revoked {
+		return revoked, err
+	}
+
+	// 检查用户级别的撤销时间点
+	before, err := s.denylist.UserRevokedBefore(ctx, claims.UserID)
+	if err != nil || before.IsZero() {
+		return false, err
+	}
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	// 解析时签发时间经float64转换后按毫秒截断，可能比实际签发时间早1毫秒，比较时留出1毫秒的容差
+	return claims.IssuedAt.Time.Before(before.Add(-time.Millisecond)), nil
+}
+
+// 获取新注册用户的默认角色，按编码查找普通用户角色，未初始化角色时不分配角色
+func defaultUserRoles(roleRepo repository.IRoleRepository) ([]*models.Role, error) {
+	role, err := roleRepo.FindByCode(models.RoleUser)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.GetLogger().Warn("未找到默认的普通用户角色，新用户将没有任何角色")
+			return nil, nil
+		}
+		return nil, err
+	}
+	return []*models.Role{role}, nil
+}
+
+// 为自动注册的账户生成不重复的用户名
+func generateUsername(userRepo repository.IUserRepository) (string, error) {
+	for i := 0; i < 3; i++ {
+		candidate := "u_" + randomHex(5)
+		if existUser, _ := userRepo.FindByUsername(candidate); existUser == nil {
+			return candidate, nil
+		}
+	}
+	return "", errors.New("生成用户名失败，请重试")
+}
+
+// 生成指定字节数的随机十六进制字符串
+func randomHex(size int) string {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,163 @@
+// Package services internal/services/auth_service_test.go
+package services
+
+import (
+	"context"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/config"
+	"star-go/pkg/logger"
+	"star-go/pkg/utils"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 内存会话仓库，只实现刷新令牌流程用到的方法
+type memorySessionRepo struct {
+	repository.ISessionRepository
+	sessions map[string]*models.UserSession
+}
+
+func (r *memorySessionRepo) FindByID(id string) (*models.UserSession, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *memorySessionRepo) Update(session *models.UserSession) error {
+	copied := *session
+	r.sessions[session.ID] = &copied
+	return nil
+}
+
+// 内存用户仓库，只实现刷新令牌流程用到的方法
+type memoryUserRepo struct {
+	repository.IUserRepository
+	users map[uint64]*models.User
+}
+
+func (r *memoryUserRepo) FindByID(id uint64) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+// 初始化日志和内存缓存
+func setupMemoryCache(t *testing.T) cache.Cache {
+	t.Helper()
+	logger.Log = zap.NewNop()
+	config.GetConfig().Cache.Type = "memory"
+	if err := cache.InitCache(); err != nil {
+		t.Fatalf("初始化缓存失败: %v", err)
+	}
+	return cache.GetCache()
+}
+
+func TestIsTokenRevokedUserRevokedBefore(t *testing.T) {
+	service := &AuthService{denylist: cache.NewTokenDenylist(setupMemoryCache(t), time.Hour)}
+	config.GetConfig().JWT = config.JWTConfig{Algorithm: utils.AlgHS256, Secret: "test-secret"}
+	if err := utils.InitJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	before := time.UnixMilli(time.Now().UnixMilli())
+	if err := service.denylist.RevokeUserBefore(ctx, 1, before); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		want     bool
+	}{
+		{"撤销前签发", before.Add(-5 * time.Millisecond), true},
+		{"撤销前一秒签发", before.Add(-time.Second), true},
+		{"同一毫秒签发", before, false},
+		{"同一毫秒签发但解析时少1毫秒", before.Add(-time.Millisecond), false},
+		{"撤销后签发", before.Add(time.Millisecond), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &utils.JWTClaims{UserID: 1}
+			claims.IssuedAt = jwt.NewNumericDate(tt.issuedAt)
+			revoked, err := service.IsTokenRevoked(ctx, claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if revoked != tt.want {
+				t.Errorf("IsTokenRevoked = %v, want %v", revoked, tt.want)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	store := setupMemoryCache(t)
+	config.GetConfig().JWT = config.JWTConfig{
+		Algorithm:       utils.AlgHS256,
+		Secret:          "test-secret",
+		AccessTokenExp:  15,
+		RefreshTokenExp: 60,
+	}
+	if err := utils.InitJWTKeys(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	session := &models.UserSession{UserID: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	session.ID = "session-1"
+	sessionRepo := &memorySessionRepo{sessions: map[string]*models.UserSession{session.ID: session}}
+	tokenStore := cache.NewRefreshTokenStore(store, utils.RefreshTokenTTL())
+	denylist := cache.NewTokenDenylist(store, utils.RefreshTokenTTL())
+	service := &AuthService{
+		userRepo:       &memoryUserRepo{users: map[uint64]*models.User{1: {BaseModel: models.BaseModel{ID: 1}, Status: 1}}},
+		sessionService: &SessionService{sessionRepo: sessionRepo, tokenStore: tokenStore, denylist: denylist},
+		tokenStore:     tokenStore,
+		denylist:       denylist,
+	}
+
+	_, stolenRefresh, err := service.issueTokens(ctx, 1, session.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 攻击者先使用窃取的刷新令牌换取新的令牌对
+	attackerAccess, _, err := service.RefreshToken(ctx, stolenRefresh, nil)
+	if err != nil {
+		t.Fatalf("首次刷新失败: %v", err)
+	}
+	claims, err := utils.ParseAccessToken(attackerAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revoked, err := service.IsTokenRevoked(ctx, claims); err != nil || revoked {
+		t.Fatalf("重放前访问令牌应有效: revoked=%v err=%v", revoked, err)
+	}
+
+	// 合法用户再次使用同一刷新令牌，触发重放检测
+	if _, _, err := service.RefreshToken(ctx, stolenRefresh, nil); err == nil {
+		t.Fatal("重复使用的刷新令牌应被拒绝")
+	}
+
+	revoked, err := service.IsTokenRevoked(ctx, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !revoked {
+		t.Error("检测到重放后，该令牌族签发的访问令牌应被拒绝")
+	}
+	if sessionRepo.sessions[session.ID].RevokedAt == nil {
+		t.Error("检测到重放后会话应被标记为已撤销")
+	}
+}
@@ -0,0 +1,353 @@
+// Package services internal/services/email_verify_service.go
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/config"
+	"star-go/pkg/logger"
+	"star-go/pkg/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 邮箱验证默认配置
+const (
+	defaultEmailVerifyTTL      = 24 * time.Hour
+	defaultEmailResendCooldown = time.Minute
+	defaultEmailDailyCap       = 10
+	emailVerifyCodeMaxAttempts = 5
+	emailVerifyCodeLength      = 6
+)
+
+// 邮箱验证相关错误
+var (
+	ErrEmailNotVerified     = errors.New("邮箱尚未验证，请先完成邮箱验证")
+	ErrInvalidVerifyToken   = errors.New("验证链接无效或已过期")
+	ErrEmailAlreadyVerified = errors.New("邮箱已验证")
+)
+
+// EmailRateLimitError 验证邮件发送频率受限错误
+type EmailRateLimitError struct {
+	Reason     string        // 受限原因
+	RetryAfter time.Duration // 距离可再次发送的时间
+}
+
+func (e *EmailRateLimitError) Error() string {
+	return fmt.Sprintf("%s，请%d秒后重试", e.Reason, e.RetryAfter/time.Second)
+}
+
+// IEmailVerifyService 邮箱验证服务接口
+type IEmailVerifyService interface {
+	// Enabled 是否开启注册邮箱验证
+	Enabled() bool
+
+	// Send 向用户邮箱发送验证链接和验证码
+	Send(ctx context.Context, user *models.User) error
+
+	// Resend 按邮箱重发验证邮件，受发送频率限制，邮箱未注册或已验证时同样返回成功
+	Resend(ctx context.Context, email string) error
+
+	// VerifyToken 校验验证链接中的签名令牌
+	VerifyToken(ctx context.Context, token string) (*models.User, error)
+
+	// VerifyCode 校验邮件中的验证码
+	VerifyCode(ctx context.Context, email, code string) (*models.User, error)
+}
+
+// EmailVerifyService 邮箱验证服务实现
+type EmailVerifyService struct {
+	userRepo       repository.IUserRepository
+	mailer         mail.Mailer
+	cache          cache.Cache
+	enabled        bool
+	secret         []byte
+	linkURL        string
+	ttl            time.Duration
+	resendCooldown time.Duration
+	dailyCap       int
+}
+
+// 邮箱验证码记录
+type emailVerifyCode struct {
+	Code     string `json:"code"`
+	Email    string `json:"email"`
+	Attempts int    `json:"attempts"`
+}
+
+// NewEmailVerifyService 根据配置创建邮箱验证服务实例
+func NewEmailVerifyService() IEmailVerifyService {
+	cfg := config.GetConfig()
+
+	secret := cfg.EmailVerify.Secret
+	if secret == "" {
+		secret = cfg.JWT.Secret
+	}
+
+	s := &EmailVerifyService{
+		userRepo:       repository.NewUserRepository(),
+		mailer:         mail.NewMailer(),
+		cache:          cache.GetCache(),
+		enabled:        cfg.EmailVerify.Enabled,
+		secret:         []byte(secret),
+		linkURL:        cfg.EmailVerify.LinkURL,
+		ttl:            cfg.EmailVerify.TTL * time.Second,
+		resendCooldown: cfg.EmailVerify.ResendCooldown * time.Second,
+		dailyCap:       cfg.EmailVerify.DailyLimit,
+	}
+	if s.ttl <= 0 {
+		s.ttl = defaultEmailVerifyTTL
+	}
+	if s.resendCooldown <= 0 {
+		s.resendCooldown = defaultEmailResendCooldown
+	}
+	if s.dailyCap <= 0 {
+		s.dailyCap = defaultEmailDailyCap
+	}
+	return s
+}
+
+// Enabled 是否开启注册邮箱验证
+func (s *EmailVerifyService) Enabled() bool {
+	return s.enabled
+}
+
+// Send 生成签名链接和验证码并发送验证邮件，重新发送会使之前的验证码失效
+func (s *EmailVerifyService) Send(ctx context.Context, user *models.User) error {
+	code := generateCode(emailVerifyCodeLength)
+	record := &emailVerifyCode{Code: code, Email: user.Email}
+	if err := s.cache.Set(ctx, generateEmailVerifyCodeKey(user.ID), record, s.ttl); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("您好 %s，请在%d小时内完成邮箱验证。\n\n验证码: %s\n", user.Nickname, int(s.ttl.Hours()), code)
+	if link := s.buildLink(user); link != "" {
+		body += fmt.Sprintf("或点击链接完成验证: %s\n", link)
+	}
+	body += "\n如非本人操作请忽略本邮件。"
+
+	return s.mailer.Send(ctx, &mail.Message{
+		To:      user.Email,
+		Subject: "请验证您的邮箱",
+		Body:    body,
+	})
+}
+
+// Resend 按邮箱重发验证邮件
+func (s *EmailVerifyService) Resend(ctx context.Context, email string) error {
+	// 频率限制先于查找用户，避免通过响应差异探测邮箱是否注册
+	if err := s.acquire(ctx, email); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.GetLogger().Info("重发验证邮件的邮箱未注册")
+			return nil
+		}
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err := s.Send(ctx, user); err != nil {
+		// 发送失败时释放冷却时间，允许立即重试
+		_ = s.cache.Delete(ctx, generateEmailCooldownKey(email))
+		return err
+	}
+	return nil
+}
+
+// VerifyToken 校验签名令牌，令牌中的邮箱必须与账户当前邮箱一致
+func (s *EmailVerifyService) VerifyToken(ctx context.Context, token string) (*models.User, error) {
+	userID, email, err := s.parseToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || user.Email != email {
+		return nil, ErrInvalidVerifyToken
+	}
+	return s.markVerified(ctx, user)
+}
+
+// VerifyCode 校验邮件中的验证码
+func (s *EmailVerifyService) VerifyCode(ctx context.Context, email, code string) (*models.User, error) {
+	user, err := s.userRepo.FindByEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, cache.ErrCodeExpired
+		}
+		return nil, err
+	}
+	if user.EmailVerified {
+		return nil, ErrEmailAlreadyVerified
+	}
+
+	key := generateEmailVerifyCodeKey(user.ID)
+	var record emailVerifyCode
+	if err := s.cache.Get(ctx, key, &record); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, cache.ErrCodeExpired
+		}
+		return nil, err
+	}
+
+	// 验证码发送后修改过邮箱时不再有效
+	if record.Email != user.Email {
+		return nil, cache.ErrCodeExpired
+	}
+	if record.Attempts >= emailVerifyCodeMaxAttempts {
+		return nil, cache.ErrTooManyAttempts
+	}
+
+	if subtle.ConstantTimeCompare([]byte(record.Code), []byte(code)) != 1 {
+		// 记录失败次数，保留原有效期
+		record.Attempts++
+		ttl, err := s.cache.TTL(ctx, key)
+		if err != nil || ttl <= 0 {
+			ttl = s.ttl
+		}
+		if err := s.cache.Set(ctx, key, &record, ttl); err != nil {
+			return nil, err
+		}
+		return nil, cache.ErrCodeMismatch
+	}
+
+	return s.markVerified(ctx, user)
+}
+
+// 将用户标记为已验证并清除验证码
+func (s *EmailVerifyService) markVerified(ctx context.Context, user *models.User) (*models.User, error) {
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	user.EmailVerified = true
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+	_ = s.cache.Delete(ctx, generateEmailVerifyCodeKey(user.ID))
+	return user, nil
+}
+
+// 检查并占用一次重发配额，受限时返回 *EmailRateLimitError
+func (s *EmailVerifyService) acquire(ctx context.Context, email string) error {
+	cooldownKey := generateEmailCooldownKey(email)
+	count, err := s.cache.Incr(ctx, cooldownKey, s.resendCooldown)
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		retryAfter, err := s.cache.TTL(ctx, cooldownKey)
+		if err != nil || retryAfter <= 0 {
+			retryAfter = s.resendCooldown
+		}
+		return &EmailRateLimitError{Reason: "验证邮件发送过于频繁", RetryAfter: retryAfter}
+	}
+
+	// 日配额按自然日计数，次日零点自动失效
+	now := time.Now()
+	untilTomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).Sub(now)
+	count, err = s.cache.Incr(ctx, generateEmailDailyKey(now.Format("20060102"), email), untilTomorrow)
+	if err != nil {
+		return err
+	}
+	if count > int64(s.dailyCap) {
+		return &EmailRateLimitError{Reason: "该邮箱今日验证邮件发送次数已达上限", RetryAfter: untilTomorrow}
+	}
+	return nil
+}
+
+// 生成验证链接，未配置链接地址时返回空字符串
+func (s *EmailVerifyService) buildLink(user *models.User) string {
+	if s.linkURL == "" {
+		return ""
+	}
+
+	token := s.signToken(user.ID, user.Email, time.Now().Add(s.ttl))
+	separator := "?"
+	if strings.Contains(s.linkURL, "?") {
+		separator = "&"
+	}
+	return s.linkURL + separator + "token=" + url.QueryEscape(token)
+}
+
+// 签发验证令牌，格式为 base64url(用户ID|邮箱|过期时间).base64url(HMAC-SHA256)
+func (s *EmailVerifyService) signToken(userID uint64, email string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%d|%s|%d", userID, email, expiresAt.Unix())
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.sign(encoded))
+}
+
+// 解析并校验验证令牌
+func (s *EmailVerifyService) parseToken(token string) (uint64, string, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, "", ErrInvalidVerifyToken
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, s.sign(encoded)) {
+		return 0, "", ErrInvalidVerifyToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, "", ErrInvalidVerifyToken
+	}
+
+	// 邮箱中可能包含分隔符，用户ID和过期时间分别取首尾字段
+	parts := strings.Split(string(payload), "|")
+	if len(parts) < 3 {
+		return 0, "", ErrInvalidVerifyToken
+	}
+	userID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidVerifyToken
+	}
+	expiresAt, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return 0, "", ErrInvalidVerifyToken
+	}
+	email := strings.Join(parts[1:len(parts)-1], "|")
+
+	return userID, email, nil
+}
+
+// 计算签名
+func (s *EmailVerifyService) sign(data string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("email_verify:" + data))
+	return mac.Sum(nil)
+}
+
+// 生成邮箱验证码缓存键
+func generateEmailVerifyCodeKey(userID uint64) string {
+	return fmt.Sprintf("email_verify:code:%d", userID)
+}
+
+// 生成重发冷却键
+func generateEmailCooldownKey(email string) string {
+	return fmt.Sprintf("email_verify:limit:cooldown:%s", strings.ToLower(email))
+}
+
+// 生成邮箱日配额键
+func generateEmailDailyKey(day, email string) string {
+	return fmt.Sprintf("email_verify:limit:daily:%s:%s", day, strings.ToLower(email))
+}
@@ -0,0 +1,175 @@
+// Package services internal/services/login_guard.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"star-go/pkg/cache"
+	"star-go/pkg/config"
+	"strings"
+	"time"
+)
+
+// 登录保护默认配置
+const (
+	defaultLoginMaxUserFailures = 5
+	defaultLoginMaxIPFailures   = 20
+	defaultLoginFailureWindow   = 15 * time.Minute
+	defaultLoginLockoutDuration = 15 * time.Minute
+	defaultLoginDelayAfter      = 3
+	defaultLoginDelayStep       = time.Second
+	defaultLoginMaxDelay        = 5 * time.Second
+)
+
+// ErrInvalidCredentials 用户名或密码错误，不区分用户是否存在
+var ErrInvalidCredentials = errors.New("用户名或密码错误")
+
+// LoginLockedError 登录失败次数过多被临时锁定
+type LoginLockedError struct {
+	RetryAfter time.Duration // 距离解锁的时间
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("登录失败次数过多，请%d秒后重试", int(e.RetryAfter.Seconds()))
+}
+
+// LoginGuard 登录失败保护接口
+type LoginGuard interface {
+	// Check 检查用户名和IP是否处于锁定状态，锁定时返回 *LoginLockedError
+	Check(ctx context.Context, username, clientIP string) error
+
+	// RecordFailure 记录一次失败，达到阈值时锁定，并按失败次数递增延迟响应
+	RecordFailure(ctx context.Context, username, clientIP string) error
+
+	// Reset 登录成功后清除用户名的失败记录
+	Reset(ctx context.Context, username string) error
+}
+
+// 基于通用缓存计数器的登录保护实现
+type loginGuard struct {
+	cache           cache.Cache
+	maxUserFailures int
+	maxIPFailures   int
+	failureWindow   time.Duration
+	lockoutDuration time.Duration
+	delayAfter      int
+	delayStep       time.Duration
+	maxDelay        time.Duration
+}
+
+// NewLoginGuard 根据配置创建登录保护
+func NewLoginGuard() LoginGuard {
+	cfg := config.GetConfig().Login
+
+	guard := &loginGuard{
+		cache:           cache.GetCache(),
+		maxUserFailures: cfg.MaxUserFailures,
+		maxIPFailures:   cfg.MaxIPFailures,
+		failureWindow:   cfg.FailureWindow * time.Second,
+		lockoutDuration: cfg.LockoutDuration * time.Second,
+		delayAfter:      cfg.DelayAfter,
+		delayStep:       cfg.DelayStep * time.Millisecond,
+		maxDelay:        cfg.MaxDelay * time.Millisecond,
+	}
+	if guard.maxUserFailures <= 0 {
+		guard.maxUserFailures = defaultLoginMaxUserFailures
+	}
+	if guard.maxIPFailures <= 0 {
+		guard.maxIPFailures = defaultLoginMaxIPFailures
+	}
+	if guard.failureWindow <= 0 {
+		guard.failureWindow = defaultLoginFailureWindow
+	}
+	if guard.lockoutDuration <= 0 {
+		guard.lockoutDuration = defaultLoginLockoutDuration
+	}
+	if guard.delayAfter <= 0 {
+		guard.delayAfter = defaultLoginDelayAfter
+	}
+	if guard.delayStep <= 0 {
+		guard.delayStep = defaultLoginDelayStep
+	}
+	if guard.maxDelay <= 0 {
+		guard.maxDelay = defaultLoginMaxDelay
+	}
+	return guard
+}
+
+// Check 检查锁定状态
+func (g *loginGuard) Check(ctx context.Context, username, clientIP string) error {
+	for _, key := range []string{generateLoginLockKey("user", normalizeLoginName(username)), generateLoginLockKey("ip", clientIP)} {
+		ttl, err := g.cache.TTL(ctx, key)
+		if err != nil {
+			if errors.Is(err, cache.ErrCacheMiss) {
+				continue
+			}
+			return err
+		}
+		return &LoginLockedError{RetryAfter: ttl}
+	}
+	return nil
+}
+
+// RecordFailure 记录失败
+func (g *loginGuard) RecordFailure(ctx context.Context, username, clientIP string) error {
+	username = normalizeLoginName(username)
+
+	userFailures, err := g.incrFailures(ctx, "user", username, g.maxUserFailures)
+	if err != nil {
+		return err
+	}
+	if _, err := g.incrFailures(ctx, "ip", clientIP, g.maxIPFailures); err != nil {
+		return err
+	}
+
+	// 连续失败超过阈值后逐步增加响应延迟，拖慢暴力破解
+	if userFailures > int64(g.delayAfter) {
+		delay := min(time.Duration(userFailures-int64(g.delayAfter))*g.delayStep, g.maxDelay)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+		}
+	}
+	return nil
+}
+
+// Reset 清除用户名失败记录，IP失败记录保留至窗口结束
+func (g *loginGuard) Reset(ctx context.Context, username string) error {
+	return g.cache.Delete(ctx, generateLoginFailureKey("user", normalizeLoginName(username)))
+}
+
+// 失败次数加一，达到上限时锁定并重新计数
+func (g *loginGuard) incrFailures(ctx context.Context, scope, subject string, limit int) (int64, error) {
+	if subject == "" {
+		return 0, nil
+	}
+
+	key := generateLoginFailureKey(scope, subject)
+	count, err := g.cache.Incr(ctx, key, g.failureWindow)
+	if err != nil {
+		return 0, err
+	}
+	if count >= int64(limit) {
+		if err := g.cache.Set(ctx, generateLoginLockKey(scope, subject), true, g.lockoutDuration); err != nil {
+			return 0, err
+		}
+		_ = g.cache.Delete(ctx, key)
+	}
+	return count, nil
+}
+
+// 用户名不区分大小写计数，避免通过大小写变化绕过限制
+func normalizeLoginName(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// 生成失败次数键
+func generateLoginFailureKey(scope, subject string) string {
+	return fmt.Sprintf("login:failures:%s:%s", scope, subject)
+}
+
+// 生成锁定键
+func generateLoginLockKey(scope, subject string) string {
+	return fmt.Sprintf("login:lock:%s:%s", scope, subject)
+}
@@ -0,0 +1,340 @@
+// Package services internal/services/mfa_service.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/config"
+	"star-go/pkg/totp"
+	"strings"
+	"time"
+)
+
+// 两步验证相关配置
+const (
+	totpSetupTTL            = 10 * time.Minute // 绑定身份验证器的时限
+	recoveryCodeCount       = 10               // 每次生成的恢复码数量
+	mfaChallengeMaxAttempts = 5                // 单个登录挑战允许的最大尝试次数
+	defaultMFAChallengeTTL  = 5 * time.Minute
+)
+
+// 两步验证相关错误
+var (
+	ErrInvalidMFACode     = errors.New("两步验证码错误")
+	ErrMFATooManyAttempts = errors.New("两步验证失败次数过多，请重新登录")
+)
+
+// TOTPSetup 身份验证器绑定信息
+type TOTPSetup struct {
+	Secret string `json:"secret"` // Base32密钥，供手动输入
+	URI    string `json:"uri"`    // otpauth链接，供生成二维码
+}
+
+// IMFAService 两步验证服务接口
+type IMFAService interface {
+	SetupTOTP(ctx context.Context, userID uint64) (*TOTPSetup, error)
+	EnableTOTP(ctx context.Context, userID uint64, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uint64, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint64, code string) ([]string, error)
+	Verify(ctx context.Context, user *models.User, code string) error
+	CreateChallenge(ctx context.Context, userID uint64) (string, error)
+	ChallengeUser(ctx context.Context, challenge string) (*models.User, error)
+	VerifyChallenge(ctx context.Context, challenge, code string) (*models.User, error)
+}
+
+// MFAService 两步验证服务实现
+type MFAService struct {
+	userRepo     repository.IUserRepository
+	recoveryRepo repository.IRecoveryCodeRepository
+	cache        cache.Cache
+}
+
+// NewMFAService 创建两步验证服务实例
+func NewMFAService() IMFAService {
+	return &MFAService{
+		userRepo:     repository.NewUserRepository(),
+		recoveryRepo: repository.NewRecoveryCodeRepository(),
+		cache:        cache.GetCache(),
+	}
+}
+
+// 登录挑战记录，尝试次数单独使用原子计数器记录
+type mfaChallenge struct {
+	UserID uint64 `json:"user_id"`
+}
+
+// SetupTOTP 生成待绑定的TOTP密钥，需调用EnableTOTP校验验证码后才会生效
+func (s *MFAService) SetupTOTP(ctx context.Context, userID uint64) (*TOTPSetup, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("已开启两步验证")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, generateTOTPSetupKey(userID), secret, totpSetupTTL); err != nil {
+		return nil, err
+	}
+
+	return &TOTPSetup{
+		Secret: secret,
+		URI:    totp.ProvisioningURI(mfaIssuer(), user.Username, secret),
+	}, nil
+}
+
+// EnableTOTP 校验身份验证器生成的验证码并开启两步验证，返回恢复码明文（仅此一次）
+func (s *MFAService) EnableTOTP(ctx context.Context, userID uint64, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if user.TOTPEnabled {
+		return nil, errors.New("已开启两步验证")
+	}
+
+	var secret string
+	if err := s.cache.Get(ctx, generateTOTPSetupKey(userID), &secret); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, errors.New("请先获取两步验证密钥")
+		}
+		return nil, err
+	}
+	if err := s.verifyTOTP(ctx, userID, secret, code); err != nil {
+		return nil, err
+	}
+
+	// 先生成恢复码，保证开启后一定可以使用恢复码登录
+	codes, err := s.resetRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPEnabled = true
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	_ = s.cache.Delete(ctx, generateTOTPSetupKey(userID))
+	return codes, nil
+}
+
+// DisableTOTP 校验两步验证码或恢复码后关闭两步验证
+func (s *MFAService) DisableTOTP(ctx context.Context, userID uint64, code string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if err := s.Verify(ctx, user, code); err != nil {
+		return err
+	}
+
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+	return s.recoveryRepo.DeleteByUserID(userID)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码全部失效
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uint64, code string) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	if err := s.Verify(ctx, user, code); err != nil {
+		return nil, err
+	}
+	return s.resetRecoveryCodes(userID)
+}
+
+// Verify 校验TOTP验证码或恢复码，恢复码使用后即失效
+func (s *MFAService) Verify(ctx context.Context, user *models.User, code string) error {
+	if !user.TOTPEnabled {
+		return errors.New("未开启两步验证")
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return s.verifyTOTP(ctx, user.ID, user.TOTPSecret, code)
+	}
+
+	ok, err := s.recoveryRepo.Consume(user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// CreateChallenge 密码验证通过后创建短期有效的登录挑战令牌
+func (s *MFAService) CreateChallenge(ctx context.Context, userID uint64) (string, error) {
+	challenge := randomHex(32)
+	record := &mfaChallenge{UserID: userID}
+	if err := s.cache.Set(ctx, generateMFAChallengeKey(challenge), record, mfaChallengeTTL()); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
+
+// ChallengeUser 获取登录挑战令牌对应的用户，不消耗尝试次数
+func (s *MFAService) ChallengeUser(ctx context.Context, challenge string) (*models.User, error) {
+	var record mfaChallenge
+	if err := s.cache.Get(ctx, generateMFAChallengeKey(challenge), &record); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, errors.New("登录已过期，请重新登录")
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(record.UserID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	return user, nil
+}
+
+// VerifyChallenge 校验登录挑战令牌和两步验证码，成功后挑战令牌失效
+func (s *MFAService) VerifyChallenge(ctx context.Context, challenge, code string) (*models.User, error) {
+	key := generateMFAChallengeKey(challenge)
+	attemptsKey := generateMFAAttemptsKey(challenge)
+
+	user, err := s.ChallengeUser(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先原子递增尝试次数再校验，并发请求也不能超过上限
+	attempts, err := s.cache.Incr(ctx, attemptsKey, mfaChallengeTTL())
+	if err != nil {
+		return nil, err
+	}
+	if attempts > mfaChallengeMaxAttempts {
+		_ = s.cache.Delete(ctx, key)
+		return nil, ErrMFATooManyAttempts
+	}
+
+	if err := s.Verify(ctx, user, code); err != nil {
+		// 用完尝试次数后需重新输入密码
+		if attempts >= mfaChallengeMaxAttempts {
+			_ = s.cache.Delete(ctx, key)
+			if errors.Is(err, ErrInvalidMFACode) {
+				return nil, ErrMFATooManyAttempts
+			}
+		}
+		return nil, err
+	}
+
+	_ = s.cache.Delete(ctx, key)
+	_ = s.cache.Delete(ctx, attemptsKey)
+	return user, nil
+}
+
+// 校验TOTP验证码，同一周期的验证码只能使用一次
+func (s *MFAService) verifyTOTP(ctx context.Context, userID uint64, secret, code string) error {
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	// 不接受早于最近一次已使用周期的验证码
+	var lastStep uint64
+	err := s.cache.Get(ctx, generateTOTPUsedKey(userID), &lastStep)
+	if err != nil && !errors.Is(err, cache.ErrCacheMiss) {
+		return err
+	}
+	if err == nil && step <= lastStep {
+		return ErrInvalidMFACode
+	}
+
+	// 原子地占用该周期，并发请求中只有计数为1的请求通过；标记保留到验证码的可接受窗口结束
+	count, err := s.cache.Incr(ctx, generateTOTPStepUsedKey(userID, step), 3*totp.Period)
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		return ErrInvalidMFACode
+	}
+	return s.cache.Set(ctx, generateTOTPUsedKey(userID), step, 3*totp.Period)
+}
+
+// 生成并保存一组新的恢复码，返回明文
+func (s *MFAService) resetRecoveryCodes(userID uint64) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		raw := randomHex(5)
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	if err := s.recoveryRepo.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// 计算恢复码哈希，忽略大小写和分隔符
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// 身份验证器中显示的发行方
+func mfaIssuer() string {
+	if issuer := config.GetConfig().MFA.Issuer; issuer != "" {
+		return issuer
+	}
+	return "Star-Go"
+}
+
+// 登录挑战令牌有效期
+func mfaChallengeTTL() time.Duration {
+	ttl := config.GetConfig().MFA.ChallengeTTL * time.Second
+	if ttl <= 0 {
+		return defaultMFAChallengeTTL
+	}
+	return ttl
+}
+
+// 生成待绑定密钥键
+func generateTOTPSetupKey(userID uint64) string {
+	return fmt.Sprintf("mfa:totp:setup:%d", userID)
+}
+
+// 生成已使用周期键
+func generateTOTPUsedKey(userID uint64) string {
+	return fmt.Sprintf("mfa:totp:used:%d", userID)
+}
+
+// 生成TOTP周期占用标记键
+func generateTOTPStepUsedKey(userID, step uint64) string {
+	return fmt.Sprintf("mfa:totp:used:%d:%d", userID, step)
+}
+
+// 生成登录挑战键
+func generateMFAChallengeKey(challenge string) string {
+	sum := sha256.Sum256([]byte(challenge))
+	return fmt.Sprintf("mfa:challenge:%s", hex.EncodeToString(sum[:]))
+}
+
+// 生成登录挑战尝试次数键
+func generateMFAAttemptsKey(challenge string) string {
+	sum := sha256.Sum256([]byte(challenge))
+	return fmt.Sprintf("mfa:challenge:attempts:%s", hex.EncodeToString(sum[:]))
+}
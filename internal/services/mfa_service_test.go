@@ -0,0 +1,51 @@
+// Package services internal/services/mfa_service_test.go
+package services
+
+import (
+	"context"
+	"errors"
+	"star-go/pkg/totp"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVerifyTOTPRejectsConcurrentReplay(t *testing.T) {
+	service := &MFAService{cache: setupMemoryCache(t)}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.GenerateCode(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const workers = 20
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := service.verifyTOTP(context.Background(), 1, secret, code)
+			if err != nil && !errors.Is(err, ErrInvalidMFACode) {
+				t.Errorf("verifyTOTP err = %v", err)
+				return
+			}
+			if err == nil {
+				mu.Lock()
+				accepted++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if accepted != 1 {
+		t.Errorf("同一周期的验证码被接受了%d次，want 1", accepted)
+	}
+}
@@ -0,0 +1,355 @@
+// Package services internal/services/oauth_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/config"
+	"star-go/pkg/logger"
+	"star-go/pkg/oidc"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultOAuthStateTTL = 10 * time.Minute
+	oauthPlaceholderHost = "oauth.star-go.local" // 外部账户未提供邮箱时使用的占位邮箱域名
+)
+
+// 第三方登录相关错误
+var (
+	ErrOAuthProviderNotFound = errors.New("不支持的登录方式")
+	ErrOAuthInvalidState     = errors.New("授权请求无效或已过期，请重新登录")
+	ErrOAuthNotLinked        = errors.New("该外部账户尚未关联，请先登录后在账户设置中绑定")
+	ErrOAuthEmailExists      = errors.New("该邮箱已注册，请使用原账户登录后绑定")
+	ErrOAuthAlreadyLinked    = errors.New("该外部账户已关联其他用户")
+	ErrOAuthLastIdentity     = errors.New("账户未设置可找回的邮箱或手机号，不能解除唯一的外部账户关联")
+)
+
+// OAuthProviderInfo 第三方登录提供方信息
+type OAuthProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// OAuthCallbackResult 授权回调结果，登录和绑定两种模式分别填充对应字段
+type OAuthCallbackResult struct {
+	Login    *LoginResult         // 登录模式的登录结果
+	Identity *models.UserIdentity // 绑定模式新关联的外部身份
+}
+
+// IOAuthService 第三方登录服务接口
+type IOAuthService interface {
+	Providers() []OAuthProviderInfo
+	AuthURL(ctx context.Context, provider string, linkUserID uint64) (string, error)
+	Callback(ctx context.Context, provider, state, code string, client *ClientInfo) (*OAuthCallbackResult, error)
+	ListIdentities(userID uint64) ([]*models.UserIdentity, error)
+	Unlink(userID uint64, provider string) error
+}
+
+// OAuthService 第三方登录服务实现
+type OAuthService struct {
+	userRepo     repository.IUserRepository
+	identityRepo repository.IIdentityRepository
+	roleRepo     repository.IRoleRepository
+	authService  IAuthService
+	cache        cache.Cache
+	providers    map[string]*oidc.Provider
+	names        []string // 保持配置中的顺序
+	stateTTL     time.Duration
+}
+
+// 授权请求状态，回调时通过state取回
+type oauthState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	LinkUserID   uint64 `json:"link_user_id,omitempty"` // 非零表示为该用户绑定外部账户
+}
+
+// NewOAuthService 根据配置创建第三方登录服务实例
+func NewOAuthService() IOAuthService {
+	cfg := config.GetConfig().OAuth
+
+	s := &OAuthService{
+		userRepo:     repository.NewUserRepository(),
+		identityRepo: repository.NewIdentityRepository(),
+		roleRepo:     repository.NewRoleRepository(),
+		authService:  NewAuthService(),
+		cache:        cache.GetCache(),
+		providers:    make(map[string]*oidc.Provider),
+		stateTTL:     cfg.StateTTL * time.Second,
+	}
+	if s.stateTTL <= 0 {
+		s.stateTTL = defaultOAuthStateTTL
+	}
+
+	for _, providerCfg := range cfg.Providers {
+		if providerCfg.Name == "" || providerCfg.ClientID == "" {
+			logger.GetLogger().Warn("忽略不完整的OIDC提供方配置", zap.String("name", providerCfg.Name))
+			continue
+		}
+		if _, exists := s.providers[providerCfg.Name]; exists {
+			logger.GetLogger().Warn("忽略重复的OIDC提供方配置", zap.String("name", providerCfg.Name))
+			continue
+		}
+		s.providers[providerCfg.Name] = oidc.NewProvider(providerCfg)
+		s.names = append(s.names, providerCfg.Name)
+	}
+	return s
+}
+
+// Providers 获取已配置的提供方列表
+func (s *OAuthService) Providers() []OAuthProviderInfo {
+	list := make([]OAuthProviderInfo, 0, len(s.names))
+	for _, name := range s.names {
+		list = append(list, OAuthProviderInfo{
+			Name:        name,
+			DisplayName: s.providers[name].DisplayName(),
+		})
+	}
+	return list
+}
+
+// AuthURL 生成授权地址，state、nonce和PKCE校验码保存在缓存中，linkUserID非零时为绑定模式
+func (s *OAuthService) AuthURL(ctx context.Context, provider string, linkUserID uint64) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrOAuthProviderNotFound
+	}
+
+	state := oidc.GenerateRandom()
+	record := &oauthState{
+		Provider:     provider,
+		Nonce:        oidc.GenerateRandom(),
+		CodeVerifier: oidc.GenerateRandom(),
+		LinkUserID:   linkUserID,
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, record.Nonce, record.CodeVerifier)
+	if err != nil {
+		return "", err
+	}
+	if err := s.cache.Set(ctx, generateOAuthStateKey(state), record, s.stateTTL); err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// Callback 处理授权回调：校验state，换取并校验id_token，然后登录或绑定
+func (s *OAuthService) Callback(ctx context.Context, provider, state, code string, client *ClientInfo) (*OAuthCallbackResult, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return nil, ErrOAuthProviderNotFound
+	}
+
+	// state只能使用一次
+	var record oauthState
+	key := generateOAuthStateKey(state)
+	if err := s.cache.Get(ctx, key, &record); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return nil, ErrOAuthInvalidState
+		}
+		return nil, err
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return nil, err
+	}
+	if record.Provider != provider {
+		return nil, ErrOAuthInvalidState
+	}
+
+	token, err := p.Exchange(ctx, code, record.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := p.VerifyIDToken(ctx, token.IDToken, record.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.LinkUserID != 0 {
+		identity, err := s.link(record.LinkUserID, provider, claims)
+		if err != nil {
+			return nil, err
+		}
+		return &OAuthCallbackResult{Identity: identity}, nil
+	}
+
+	user, err := s.findOrRegister(p, claims)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.authService.LoginExternal(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthCallbackResult{Login: result}, nil
+}
+
+// ListIdentities 获取用户关联的外部身份
+func (s *OAuthService) ListIdentities(userID uint64) ([]*models.UserIdentity, error) {
+	return s.identityRepo.ListByUserID(userID)
+}
+
+// Unlink 解除外部账户关联，无法通过其他方式找回的账户不能解除唯一的关联
+func (s *OAuthService) Unlink(userID uint64, provider string) error {
+	identities, err := s.identityRepo.ListByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if len(identities) == 1 && identities[0].Provider == provider {
+		user, err := s.userRepo.FindByID(userID)
+		if err != nil {
+			return err
+		}
+		if user.GetPhone() == "" && strings.HasSuffix(user.Email, "@"+oauthPlaceholderHost) {
+			return ErrOAuthLastIdentity
+		}
+	}
+
+	deleted, err := s.identityRepo.Delete(userID, provider)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errors.New("未关联该外部账户")
+	}
+	return nil
+}
+
+// 为已登录用户绑定外部账户
+func (s *OAuthService) link(userID uint64, provider string, claims *oidc.Claims) (*models.UserIdentity, error) {
+	identity, err := s.identityRepo.FindByProviderSubject(provider, claims.Subject)
+	if err == nil {
+		if identity.UserID != userID {
+			return nil, ErrOAuthAlreadyLinked
+		}
+		return identity, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// 每个提供方只能绑定一个外部账户
+	if _, err := s.identityRepo.FindByUserProvider(userID, provider); err == nil {
+		return nil, fmt.Errorf("已绑定其他%s账户，请先解除绑定", provider)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	identity = &models.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		Name:     claims.Name,
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// 查找外部账户关联的用户，未关联且允许自动注册时创建新用户
+// 不按邮箱自动关联已有用户，避免外部账户冒用同邮箱的本地账户
+func (s *OAuthService) findOrRegister(p *oidc.Provider, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.identityRepo.FindByProviderSubject(p.Name(), claims.Subject)
+	if err == nil {
+		// 同步外部账户资料
+		if identity.Email != claims.Email || identity.Name != claims.Name {
+			identity.Email = claims.Email
+			identity.Name = claims.Name
+			if err := s.identityRepo.Update(identity); err != nil {
+				logger.GetLogger().Warn("更新外部身份资料失败", zap.Uint64("identity_id", identity.ID), zap.Error(err))
+			}
+		}
+		return s.userRepo.FindByID(identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if !p.AutoRegister() {
+		return nil, ErrOAuthNotLinked
+	}
+	return s.register(p.Name(), claims)
+}
+
+// 使用外部账户资料注册新用户并建立关联
+func (s *OAuthService) register(provider string, claims *oidc.Claims) (*models.User, error) {
+	username, err := generateUsername(s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	// 仅使用提供方已验证的邮箱，否则使用占位邮箱
+	email := claims.Email
+	verified := claims.EmailVerified && email != ""
+	if verified {
+		if existUser, _ := s.userRepo.FindByEmail(email); existUser != nil {
+			return nil, ErrOAuthEmailExists
+		}
+	} else {
+		email = username + "@" + oauthPlaceholderHost
+	}
+
+	nickname := claims.Name
+	if nickname == "" {
+		nickname = claims.PreferredUsername
+	}
+	if nickname == "" {
+		nickname = username
+	}
+	if runes := []rune(nickname); len(runes) > 50 {
+		nickname = string(runes[:50])
+	}
+
+	roles, err := defaultUserRoles(s.roleRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username: username,
+		Email:    email,
+		Nickname: nickname,
+		Roles:    roles,
+		Status:   models.StatusActive,
+	}
+	user.EmailVerified = verified
+
+	// 设置随机密码，之后可通过找回密码设置
+	if err := user.SetPassword(randomHex(16)); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	identity := &models.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+		Name:     claims.Name,
+	}
+	if err := s.identityRepo.Create(identity); err != nil {
+		return nil, err
+	}
+
+	// 重新加载以获取角色信息
+	return s.userRepo.FindByID(user.ID)
+}
+
+// 生成授权请求状态缓存键
+func generateOAuthStateKey(state string) string {
+	return fmt.Sprintf("oauth:state:%s", state)
+}
@@ -0,0 +1,310 @@
+// Package services internal/services/password_reset_service.go
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/logger"
+	"star-go/pkg/mail"
+	"star-go/pkg/phonenumber"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 找回密码的验证渠道
+const (
+	ResetChannelSMS   = "sms"   // 短信验证码
+	ResetChannelEmail = "email" // 邮箱验证码
+)
+
+const (
+	resetCodeTTL         = 10 * time.Minute // 邮箱验证码有效期
+	resetCodeMaxAttempts = 5                // 邮箱验证码最大尝试次数
+	resetTicketTTL       = 10 * time.Minute // 重置凭证有效期
+	resetEmailCooldown   = time.Minute      // 同一邮箱验证码的重发间隔
+	resetEmailDailyCap   = 10               // 每个邮箱每日发送上限
+)
+
+// IPasswordResetService 找回密码服务接口
+type IPasswordResetService interface {
+	SendCode(ctx context.Context, channel, target, clientIP string) error
+	VerifyCode(ctx context.Context, channel, target, code string) (string, error)
+	ResetPassword(ctx context.Context, ticket, newPassword string) error
+}
+
+// PasswordResetService 找回密码服务实现
+type PasswordResetService struct {
+	userRepo        repository.IUserRepository
+	authService     IAuthService
+	passwordService IPasswordService
+	smsService      SMSService
+	mailer          mail.Mailer
+	cache           cache.Cache
+}
+
+// NewPasswordResetService 创建找回密码服务实例
+func NewPasswordResetService() IPasswordResetService {
+	return &PasswordResetService{
+		userRepo:        repository.NewUserRepository(),
+		authService:     NewAuthService(),
+		passwordService: NewPasswordService(),
+		smsService:      NewSMSService(),
+		mailer:          mail.NewMailer(),
+		cache:           cache.GetCache(),
+	}
+}
+
+// SendCode 向手机号或邮箱发送找回密码验证码
+// 为避免泄露账户是否存在，目标未注册时同样返回成功，发送频率限制也在查找账户之前执行
+func (s *PasswordResetService) SendCode(ctx context.Context, channel, target, clientIP string) error {
+	target, err := normalizeResetTarget(channel, target)
+	if err != nil {
+		return err
+	}
+
+	// 检查发送频率
+	switch channel {
+	case ResetChannelSMS:
+		err = s.smsService.Limiter().Acquire(ctx, BizResetPwd, target, clientIP)
+	case ResetChannelEmail:
+		err = s.acquireEmail(ctx, target)
+	default:
+		return errors.New("无效的验证渠道")
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.findUser(channel, target); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			logger.GetLogger().Info("找回密码的目标账户不存在", zap.String("channel", channel))
+			return nil
+		}
+		return err
+	}
+
+	if channel == ResetChannelSMS {
+		return s.smsService.Deliver(ctx, BizResetPwd, target)
+	}
+
+	// 重新发送时清零尝试次数，发送频率受上面的限制约束
+	code := generateCode(cache.SMSCodeLength())
+	if err := s.cache.Set(ctx, generateEmailResetKey(target), code, resetCodeTTL); err != nil {
+		return err
+	}
+	if err := s.cache.Delete(ctx, generateEmailResetAttemptsKey(target)); err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, &mail.Message{
+		To:      target,
+		Subject: "找回密码验证码",
+		Body:    fmt.Sprintf("您正在找回密码，验证码是: %s，有效期%d分钟。如非本人操作请忽略。", code, int(resetCodeTTL.Minutes())),
+	})
+}
+
+// 占用一次邮箱验证码发送配额，依次检查冷却时间和每日上限
+func (s *PasswordResetService) acquireEmail(ctx context.Context, email string) error {
+	cooldownKey := generateEmailResetCooldownKey(email)
+	count, err := s.cache.Incr(ctx, cooldownKey, resetEmailCooldown)
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		retryAfter, err := s.cache.TTL(ctx, cooldownKey)
+		if err != nil || retryAfter <= 0 {
+			retryAfter = resetEmailCooldown
+		}
+		return &EmailRateLimitError{Reason: "验证码发送过于频繁", RetryAfter: retryAfter}
+	}
+
+	// 日配额按自然日计数，次日零点自动失效
+	now := time.Now()
+	untilTomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).Sub(now)
+	count, err = s.cache.Incr(ctx, generateEmailResetDailyKey(now.Format("20060102"), email), untilTomorrow)
+	if err != nil {
+		return err
+	}
+	if count > resetEmailDailyCap {
+		return &EmailRateLimitError{Reason: "该邮箱今日验证码发送次数已达上限", RetryAfter: untilTomorrow}
+	}
+	return nil
+}
+
+// VerifyCode 校验验证码，成功后返回一次性的重置凭证
+func (s *PasswordResetService) VerifyCode(ctx context.Context, channel, target, code string) (string, error) {
+	target, err := normalizeResetTarget(channel, target)
+	if err != nil {
+		return "", err
+	}
+	switch channel {
+	case ResetChannelSMS:
+		ok, err := s.smsService.Verify(ctx, BizResetPwd, target, code)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", errors.New("验证码错误")
+		}
+	case ResetChannelEmail:
+		if err := s.verifyEmailCode(ctx, target, code); err != nil {
+			return "", err
+		}
+	default:
+		return "", errors.New("无效的验证渠道")
+	}
+
+	// 验证码正确后查找用户
+	user, err := s.findUser(channel, target)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("验证码错误")
+		}
+		return "", err
+	}
+
+	// 签发一次性重置凭证，缓存中只保存其哈希
+	ticket := randomHex(32)
+	if err := s.cache.Set(ctx, generateResetTicketKey(ticket), user.ID, resetTicketTTL); err != nil {
+		return "", err
+	}
+	return ticket, nil
+}
+
+// ResetPassword 使用重置凭证设置新密码，并撤销该用户已有的所有会话
+func (s *PasswordResetService) ResetPassword(ctx context.Context, ticket, newPassword string) error {
+	// 获取凭证
+	var userID uint64
+	key := generateResetTicketKey(ticket)
+	if err := s.cache.Get(ctx, key, &userID); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return errors.New("重置凭证无效或已过期")
+		}
+		return err
+	}
+
+	// 查找用户
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+
+	// 修改密码前原子地占用凭证，并发请求中只有一个能继续，保证凭证只能使用一次
+	usedKey := generateResetTicketUsedKey(ticket)
+	count, err := s.cache.Incr(ctx, usedKey, resetTicketTTL)
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		return errors.New("重置凭证无效或已过期")
+	}
+
+	// 按密码策略设置新密码，不符合策略时释放占用，凭证仍可继续使用
+	if err := s.passwordService.SetPassword(user, newPassword); err != nil {
+		_ = s.cache.Delete(ctx, usedKey)
+		return err
+	}
+
+	// 删除凭证
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return err
+	}
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	// 撤销已有会话，要求所有设备重新登录
+	return s.authService.LogoutAll(ctx, user.ID)
+}
+
+// 校验邮箱验证码，尝试次数使用原子计数器，并发请求也不能超过上限
+func (s *PasswordResetService) verifyEmailCode(ctx context.Context, email, code string) error {
+	key := generateEmailResetKey(email)
+	attemptsKey := generateEmailResetAttemptsKey(email)
+
+	var stored string
+	if err := s.cache.Get(ctx, key, &stored); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return errors.New("验证码失效")
+		}
+		return err
+	}
+
+	// 先递增尝试次数再比对
+	attempts, err := s.cache.Incr(ctx, attemptsKey, resetCodeTTL)
+	if err != nil {
+		return err
+	}
+	if attempts > resetCodeMaxAttempts {
+		_ = s.cache.Delete(ctx, key)
+		return errors.New("验证次数过多，请重新获取")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(stored), []byte(code)) != 1 {
+		return errors.New("验证码不匹配，请重新输入")
+	}
+
+	_ = s.cache.Delete(ctx, attemptsKey)
+	return s.cache.Delete(ctx, key)
+}
+
+// 根据渠道查找用户
+func (s *PasswordResetService) findUser(channel, target string) (*models.User, error) {
+	switch channel {
+	case ResetChannelSMS:
+		return s.userRepo.FindByPhone(target)
+	case ResetChannelEmail:
+		return s.userRepo.FindByEmail(target)
+	default:
+		return nil, errors.New("无效的验证渠道")
+	}
+}
+
+// 统一目标格式：手机号转为E.164格式，邮箱去除首尾空白并转为小写，缓存键和账户查找都使用统一后的目标
+func normalizeResetTarget(channel, target string) (string, error) {
+	if channel == ResetChannelSMS {
+		return phonenumber.Normalize(target)
+	}
+	return strings.ToLower(strings.TrimSpace(target)), nil
+}
+
+// 生成邮箱验证码缓存键
+func generateEmailResetKey(email string) string {
+	return fmt.Sprintf("reset_pwd:email:%s", email)
+}
+
+// 生成邮箱验证码尝试次数键
+func generateEmailResetAttemptsKey(email string) string {
+	return fmt.Sprintf("reset_pwd:email:attempts:%s", email)
+}
+
+// 生成邮箱验证码发送冷却键
+func generateEmailResetCooldownKey(email string) string {
+	return fmt.Sprintf("reset_pwd:limit:cooldown:%s", email)
+}
+
+// 生成邮箱验证码日配额键
+func generateEmailResetDailyKey(day, email string) string {
+	return fmt.Sprintf("reset_pwd:limit:daily:%s:%s", day, email)
+}
+
+// 生成重置凭证缓存键
+func generateResetTicketKey(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return fmt.Sprintf("reset_pwd:ticket:%s", hex.EncodeToString(sum[:]))
+}
+
+// 生成重置凭证占用标记键
+func generateResetTicketUsedKey(ticket string) string {
+	sum := sha256.Sum256([]byte(ticket))
+	return fmt.Sprintf("reset_pwd:ticket:used:%s", hex.EncodeToString(sum[:]))
+}
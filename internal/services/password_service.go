@@ -0,0 +1,81 @@
+// Package services internal/services/password_service.go
+package services
+
+import (
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/logger"
+	"star-go/pkg/password"
+
+	"go.uber.org/zap"
+)
+
+// IPasswordService 密码策略服务接口
+type IPasswordService interface {
+	// SetPassword 校验密码策略和历史密码后设置新密码，调用方负责保存用户
+	SetPassword(user *models.User, newPassword string) error
+
+	// RehashIfNeeded 登录成功后，如密码哈希的算法或参数已过时则重新计算，调用方负责保存用户
+	RehashIfNeeded(user *models.User, plain string) bool
+}
+
+// PasswordService 密码策略服务实现
+type PasswordService struct {
+	historyRepo repository.IPasswordHistoryRepository
+}
+
+// NewPasswordService 创建密码策略服务实例
+func NewPasswordService() IPasswordService {
+	return &PasswordService{
+		historyRepo: repository.NewPasswordHistoryRepository(),
+	}
+}
+
+// SetPassword 设置新密码
+func (s *PasswordService) SetPassword(user *models.User, newPassword string) error {
+	if err := password.Validate(newPassword, user.Username); err != nil {
+		return err
+	}
+
+	// 检查是否与当前密码及最近使用过的密码相同
+	historySize := password.HistorySize()
+	if historySize > 0 && user.ID != 0 {
+		if user.Password != "" && user.CheckPassword(newPassword) {
+			return password.ErrPasswordReused
+		}
+		hashes, err := s.historyRepo.ListRecent(user.ID, historySize)
+		if err != nil {
+			return err
+		}
+		for _, hash := range hashes {
+			if password.Verify(newPassword, hash) {
+				return password.ErrPasswordReused
+			}
+		}
+	}
+
+	oldHash := user.Password
+	if err := user.SetPassword(newPassword); err != nil {
+		return err
+	}
+
+	// 记录被替换的密码
+	if historySize > 0 && user.ID != 0 && oldHash != "" {
+		if err := s.historyRepo.Add(user.ID, oldHash, historySize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RehashIfNeeded 升级过时的密码哈希
+func (s *PasswordService) RehashIfNeeded(user *models.User, plain string) bool {
+	if !user.PasswordNeedsRehash() {
+		return false
+	}
+	if err := user.SetPassword(plain); err != nil {
+		logger.GetLogger().Warn("升级密码哈希失败", zap.Uint64("user_id", user.ID), zap.Error(err))
+		return false
+	}
+	return true
+}
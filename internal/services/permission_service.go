@@ -0,0 +1,210 @@
+// Package services internal/services/permission_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/logger"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	permissionCacheTTL        = 10 * time.Minute     // 用户有效权限的缓存时间，也是失效通知丢失时的最长延迟
+	permissionVersionCacheKey = "permission:version" // 权限版本号，角色变更时递增使所有用户的缓存失效
+)
+
+// 权限解析和授权相关错误
+var (
+	ErrPermissionUserNotFound = errors.New("用户不存在")
+	ErrPermissionNotHeld      = errors.New("不能授予自己未拥有的权限")
+	ErrSuperuserOnly          = errors.New("只有超级管理员可以授予全部权限或超级管理员角色")
+)
+
+// UserPermissions 用户的有效角色和权限，包含多角色合并和继承后的结果
+type UserPermissions struct {
+	UserID      uint64             `json:"user_id"`
+	Roles       []string           `json:"roles"`
+	Permissions models.Permissions `json:"permissions"`
+}
+
+// HasRole 检查是否拥有指定编码的角色
+func (p *UserPermissions) HasRole(code string) bool {
+	for _, role := range p.Roles {
+		if role == code {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission 检查是否拥有指定权限
+func (p *UserPermissions) HasPermission(permission string) bool {
+	return p.Permissions.HasPermission(permission)
+}
+
+// IsSuperuser 是否拥有超级管理员角色
+func (p *UserPermissions) IsSuperuser() bool {
+	return p.HasRole(models.RoleSuperuser)
+}
+
+// CanGrant 检查是否可以把指定权限授予角色或用户：全部权限只能由超级管理员授予；
+// 其他权限需自身拥有，且通配权限不能覆盖自身被拒绝的权限；拒绝项只会收窄权限，总是可以授予
+func (p *UserPermissions) CanGrant(permission string) bool {
+	if p.IsSuperuser() || strings.HasPrefix(permission, models.PermDenyPrefix) {
+		return true
+	}
+	if permission == models.PermAll || !p.HasPermission(permission) {
+		return false
+	}
+	for _, perm := range p.Permissions {
+		if strings.HasPrefix(perm, models.PermDenyPrefix) &&
+			models.MatchPermission(permission, strings.TrimPrefix(perm, models.PermDenyPrefix)) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckGrantPermissions 检查是否可以授予全部指定权限，返回第一个无法授予的权限
+func (p *UserPermissions) CheckGrantPermissions(permissions models.Permissions) error {
+	for _, permission := range permissions {
+		if p.CanGrant(permission) {
+			continue
+		}
+		if permission == models.PermAll {
+			return ErrSuperuserOnly
+		}
+		return fmt.Errorf("%w: %s", ErrPermissionNotHeld, permission)
+	}
+	return nil
+}
+
+// CheckGrantRole 检查是否可以分配角色或将其设为父角色，角色包含继承在内的有效权限都必须可以授予，需已加载父角色
+func (p *UserPermissions) CheckGrantRole(role *models.Role) error {
+	if role.Code == models.RoleSuperuser && !p.IsSuperuser() {
+		return ErrSuperuserOnly
+	}
+	return p.CheckGrantPermissions(role.EffectivePermissions())
+}
+
+// WithScopes 按API密钥的授权范围收窄权限，只保留授权范围内的权限，并加上双方的拒绝项；
+// 与角色中间件一致，授权范围不包含全部权限时不视为拥有任何角色
+func (p *UserPermissions) WithScopes(scopes models.Permissions) *UserPermissions {
+	narrowed := &UserPermissions{UserID: p.UserID, Roles: []string{}, Permissions: models.Permissions{}}
+	if scopes.HasPermission(models.PermAll) {
+		narrowed.Roles = p.Roles
+	}
+	for _, permission := range p.Permissions {
+		if strings.HasPrefix(permission, models.PermDenyPrefix) || scopes.HasPermission(permission) {
+			narrowed.Permissions.AddPermission(permission)
+		}
+	}
+	for _, scope := range scopes {
+		if strings.HasPrefix(scope, models.PermDenyPrefix) {
+			narrowed.Permissions.AddPermission(scope)
+		}
+	}
+	return narrowed
+}
+
+// IPermissionService 权限解析服务接口
+type IPermissionService interface {
+	Resolve(ctx context.Context, userID uint64) (*UserPermissions, error)
+	InvalidateUser(ctx context.Context, userID uint64) error
+	InvalidateAll(ctx context.Context) error
+}
+
+// PermissionService 权限解析服务实现，解析结果缓存在通用缓存中
+type PermissionService struct {
+	userRepo repository.IUserRepository
+	cache    cache.Cache
+}
+
+// NewPermissionService 创建权限解析服务实例
+func NewPermissionService() IPermissionService {
+	return &PermissionService{
+		userRepo: repository.NewUserRepository(),
+		cache:    cache.GetCache(),
+	}
+}
+
+// Resolve 获取用户的有效角色和权限，优先读取缓存，缓存不可用时直接查询数据库
+func (s *PermissionService) Resolve(ctx context.Context, userID uint64) (*UserPermissions, error) {
+	version, versionErr := s.version(ctx)
+	if versionErr != nil {
+		logger.GetLogger().Warn("读取权限版本失败", zap.Error(versionErr))
+	}
+	key := permissionCacheKey(userID, version)
+
+	if versionErr == nil {
+		var cached UserPermissions
+		err := s.cache.Get(ctx, key, &cached)
+		if err == nil {
+			return &cached, nil
+		}
+		if !errors.Is(err, cache.ErrCacheMiss) {
+			logger.GetLogger().Warn("读取权限缓存失败", zap.Uint64("user_id", userID), zap.Error(err))
+		}
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPermissionUserNotFound
+		}
+		return nil, err
+	}
+
+	resolved := &UserPermissions{
+		UserID:      user.ID,
+		Roles:       user.RoleCodes(),
+		Permissions: user.EffectivePermissions(),
+	}
+	// 版本号未知时不写缓存，避免写入的缓存无法被失效
+	if versionErr == nil {
+		if err := s.cache.Set(ctx, key, resolved, permissionCacheTTL); err != nil {
+			logger.GetLogger().Warn("写入权限缓存失败", zap.Uint64("user_id", userID), zap.Error(err))
+		}
+	}
+	return resolved, nil
+}
+
+// InvalidateUser 使单个用户的权限缓存失效，用户角色变更或用户被删除时调用
+func (s *PermissionService) InvalidateUser(ctx context.Context, userID uint64) error {
+	version, err := s.version(ctx)
+	if err != nil {
+		return err
+	}
+	return s.cache.Delete(ctx, permissionCacheKey(userID, version))
+}
+
+// InvalidateAll 使所有用户的权限缓存失效，角色权限或继承关系变更时调用
+func (s *PermissionService) InvalidateAll(ctx context.Context) error {
+	_, err := s.cache.Incr(ctx, permissionVersionCacheKey, 0)
+	return err
+}
+
+// 获取当前权限版本号，未设置时为0
+func (s *PermissionService) version(ctx context.Context) (int64, error) {
+	var version int64
+	if err := s.cache.Get(ctx, permissionVersionCacheKey, &version); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+// 生成用户权限缓存键，键中包含版本号，版本递增后旧缓存自然失效
+func permissionCacheKey(userID uint64, version int64) string {
+	return fmt.Sprintf("permission:user:%d:v%d", userID, version)
+}
@@ -0,0 +1,177 @@
+// Package services internal/services/phone_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/phonenumber"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 原手机号验证通过后的有效期，需在此时间内完成新手机号验证
+const changePhoneVerifiedTTL = 10 * time.Minute
+
+// IPhoneService 手机号绑定服务接口
+type IPhoneService interface {
+	SendOldPhoneCode(ctx context.Context, userID uint64, clientIP string) error
+	VerifyOldPhone(ctx context.Context, userID uint64, code string) error
+	SendNewPhoneCode(ctx context.Context, userID uint64, newPhone, clientIP string) error
+	ChangePhone(ctx context.Context, userID uint64, newPhone, code string) error
+}
+
+// PhoneService 手机号绑定服务实现
+type PhoneService struct {
+	userRepo   repository.IUserRepository
+	smsService SMSService
+	cache      cache.Cache
+}
+
+// NewPhoneService 创建手机号绑定服务实例
+func NewPhoneService() IPhoneService {
+	return &PhoneService{
+		userRepo:   repository.NewUserRepository(),
+		smsService: NewSMSService(),
+		cache:      cache.GetCache(),
+	}
+}
+
+// SendOldPhoneCode 向当前绑定的手机号发送验证码
+func (s *PhoneService) SendOldPhoneCode(ctx context.Context, userID uint64, clientIP string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.Phone == nil {
+		return errors.New("当前未绑定手机号")
+	}
+
+	return s.smsService.Send(ctx, BizChangePhone, user.GetPhone(), clientIP)
+}
+
+// VerifyOldPhone 校验原手机号验证码
+func (s *PhoneService) VerifyOldPhone(ctx context.Context, userID uint64, code string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+	if user.Phone == nil {
+		return errors.New("当前未绑定手机号")
+	}
+
+	ok, err := s.smsService.Verify(ctx, BizChangePhone, user.GetPhone(), code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("验证码错误")
+	}
+
+	// 记录原手机号已验证，绑定到当前手机号防止期间被修改
+	return s.cache.Set(ctx, generateOldPhoneVerifiedKey(userID), user.GetPhone(), changePhoneVerifiedTTL)
+}
+
+// SendNewPhoneCode 向新手机号发送验证码
+func (s *PhoneService) SendNewPhoneCode(ctx context.Context, userID uint64, newPhone, clientIP string) error {
+	newPhone, err := phonenumber.Normalize(newPhone)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+
+	// 已绑定手机号的用户需先完成原手机号验证
+	if err := s.checkOldPhoneVerified(ctx, userID, user.GetPhone()); err != nil {
+		return err
+	}
+	if err := s.checkPhoneAvailable(userID, user.GetPhone(), newPhone); err != nil {
+		return err
+	}
+
+	return s.smsService.Send(ctx, BizChangePhone, newPhone, clientIP)
+}
+
+// ChangePhone 校验新手机号验证码并完成换绑
+func (s *PhoneService) ChangePhone(ctx context.Context, userID uint64, newPhone, code string) error {
+	newPhone, err := phonenumber.Normalize(newPhone)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return errors.New("用户不存在")
+	}
+
+	if err := s.checkOldPhoneVerified(ctx, userID, user.GetPhone()); err != nil {
+		return err
+	}
+	if err := s.checkPhoneAvailable(userID, user.GetPhone(), newPhone); err != nil {
+		return err
+	}
+
+	// 校验新手机号验证码
+	ok, err := s.smsService.Verify(ctx, BizChangePhone, newPhone, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("验证码错误")
+	}
+
+	// 更新手机号，唯一索引兜底并发绑定
+	user.SetPhone(newPhone)
+	if err := s.userRepo.Update(user); err != nil {
+		return fmt.Errorf("绑定手机号失败: %w", err)
+	}
+
+	// 清除原手机号验证状态
+	return s.cache.Delete(ctx, generateOldPhoneVerifiedKey(userID))
+}
+
+// 检查原手机号是否已验证，未绑定手机号时无需验证
+func (s *PhoneService) checkOldPhoneVerified(ctx context.Context, userID uint64, oldPhone string) error {
+	if oldPhone == "" {
+		return nil
+	}
+
+	var verifiedPhone string
+	if err := s.cache.Get(ctx, generateOldPhoneVerifiedKey(userID), &verifiedPhone); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return errors.New("请先验证原手机号")
+		}
+		return err
+	}
+	if verifiedPhone != oldPhone {
+		return errors.New("请先验证原手机号")
+	}
+	return nil
+}
+
+// 检查新手机号是否可以绑定
+func (s *PhoneService) checkPhoneAvailable(userID uint64, oldPhone, newPhone string) error {
+	if newPhone == oldPhone {
+		return errors.New("新手机号不能与原手机号相同")
+	}
+
+	existUser, err := s.userRepo.FindByPhone(newPhone)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if existUser != nil && existUser.ID != userID {
+		return errors.New("该手机号已被其他账户绑定")
+	}
+	return nil
+}
+
+// 生成原手机号已验证标记键
+func generateOldPhoneVerifiedKey(userID uint64) string {
+	return fmt.Sprintf("change_phone:verified:%d", userID)
+}
@@ -0,0 +1,319 @@
+// Package services internal/services/role_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/logger"
+	"strings"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 角色管理相关错误
+var (
+	ErrRoleNotFound  = errors.New("角色不存在")
+	ErrRoleBuiltin   = errors.New("系统内置角色不能删除")
+	ErrRoleInUse     = errors.New("角色仍有用户在使用，不能删除")
+	ErrRoleProtected = errors.New("超级管理员角色的权限不能修改")
+	ErrRoleCycle     = errors.New("角色继承关系不能形成循环")
+	ErrRoleHasChild  = errors.New("角色仍被其他角色继承，不能删除")
+)
+
+// IRoleService 角色服务接口
+type IRoleService interface {
+	GetRoleByID(id uint64) (*models.Role, error)
+	ListRoles(page, pageSize int, search string) ([]*models.Role, int64, error)
+	CreateRole(grantor *UserPermissions, role *models.Role) error
+	UpdateRole(grantor *UserPermissions, role *models.Role) error
+	DeleteRole(id uint64) error
+	AddPermission(grantor *UserPermissions, id uint64, permission string) (*models.Role, error)
+	RemovePermission(grantor *UserPermissions, id uint64, permission string) (*models.Role, error)
+}
+
+// RoleService 角色服务实现
+type RoleService struct {
+	roleRepo          repository.IRoleRepository
+	permissionService IPermissionService
+}
+
+// NewRoleService 创建角色服务实例
+func NewRoleService() IRoleService {
+	return &RoleService{
+		roleRepo:          repository.NewRoleRepository(),
+		permissionService: NewPermissionService(),
+	}
+}
+
+// 根据ID获取角色
+func (s *RoleService) GetRoleByID(id uint64) (*models.Role, error) {
+	role, err := s.roleRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	return role, nil
+}
+
+// 获取角色列表
+func (s *RoleService) ListRoles(page, pageSize int, search string) ([]*models.Role, int64, error) {
+	return s.roleRepo.List(page, pageSize, search)
+}
+
+// 创建角色，角色包含继承在内的有效权限必须都是操作者可以授予的
+func (s *RoleService) CreateRole(grantor *UserPermissions, role *models.Role) error {
+	// 检查编码是否已存在
+	existingRole, _ := s.roleRepo.FindByCode(role.Code)
+	if existingRole != nil {
+		return errors.New("角色编码已存在")
+	}
+
+	// 检查名称是否已存在
+	existingRole, _ = s.roleRepo.FindByName(role.Name)
+	if existingRole != nil {
+		return errors.New("角色名称已存在")
+	}
+
+	// 校验父角色
+	if err := s.checkParent(role); err != nil {
+		return err
+	}
+
+	// 校验并去重权限
+	permissions, err := normalizePermissions(role.Permissions)
+	if err != nil {
+		return err
+	}
+	role.Permissions = permissions
+
+	// 不能通过自身权限或父角色获得操作者未拥有的权限
+	if err := grantor.CheckGrantRole(role); err != nil {
+		return err
+	}
+
+	// 创建角色
+	return s.roleRepo.Create(role)
+}
+
+// 更新角色，更新后角色包含继承在内的有效权限必须都是操作者可以授予的
+func (s *RoleService) UpdateRole(grantor *UserPermissions, role *models.Role) error {
+	// 检查角色是否存在
+	existingRole, err := s.GetRoleByID(role.ID)
+	if err != nil {
+		return err
+	}
+
+	// 内置角色的编码被代码引用，不能修改
+	if models.IsBuiltinRole(existingRole.Code) && role.Code != existingRole.Code {
+		return errors.New("系统内置角色的编码不能修改")
+	}
+	if existingRole.Code == models.RoleSuperuser && !samePermissions(existingRole.Permissions, role.Permissions) {
+		return ErrRoleProtected
+	}
+
+	// 如果更新了编码，检查是否与其他角色冲突
+	if role.Code != existingRole.Code {
+		conflictRole, _ := s.roleRepo.FindByCode(role.Code)
+		if conflictRole != nil && conflictRole.ID != role.ID {
+			return errors.New("角色编码已存在")
+		}
+	}
+
+	// 如果更新了名称，检查是否与其他角色冲突
+	if role.Name != existingRole.Name {
+		conflictRole, _ := s.roleRepo.FindByName(role.Name)
+		if conflictRole != nil && conflictRole.ID != role.ID {
+			return errors.New("角色名称已存在")
+		}
+	}
+
+	// 校验父角色，不能形成循环继承
+	if err := s.checkParent(role); err != nil {
+		return err
+	}
+
+	// 校验并去重权限
+	permissions, err := normalizePermissions(role.Permissions)
+	if err != nil {
+		return err
+	}
+	role.Permissions = permissions
+	role.CreatedAt = existingRole.CreatedAt
+
+	// 不能通过自身权限或父角色获得操作者未拥有的权限
+	if err := grantor.CheckGrantRole(role); err != nil {
+		return err
+	}
+
+	// 更新角色
+	if err := s.roleRepo.Update(role); err != nil {
+		return err
+	}
+	s.invalidatePermissions()
+	return nil
+}
+
+// 删除角色，内置角色和仍被用户使用的角色不能删除
+func (s *RoleService) DeleteRole(id uint64) error {
+	// 检查角色是否存在
+	role, err := s.GetRoleByID(id)
+	if err != nil {
+		return err
+	}
+	if models.IsBuiltinRole(role.Code) {
+		return ErrRoleBuiltin
+	}
+
+	// 检查是否仍有用户使用该角色
+	count, err := s.roleRepo.CountUsers(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w（%d个用户）", ErrRoleInUse, count)
+	}
+
+	// 检查是否仍有子角色继承该角色
+	count, err = s.roleRepo.CountChildren(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w（%d个子角色）", ErrRoleHasChild, count)
+	}
+
+	// 删除角色
+	if err := s.roleRepo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidatePermissions()
+	return nil
+}
+
+// 为角色添加权限，只能添加操作者可以授予的权限
+func (s *RoleService) AddPermission(grantor *UserPermissions, id uint64, permission string) (*models.Role, error) {
+	if err := models.ValidatePermission(permission); err != nil {
+		return nil, err
+	}
+	if err := grantor.CheckGrantPermissions(models.Permissions{permission}); err != nil {
+		return nil, err
+	}
+
+	role, err := s.GetRoleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if role.Code == models.RoleSuperuser {
+		return nil, ErrRoleProtected
+	}
+
+	role.AddPermission(permission)
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+	s.invalidatePermissions()
+	return role, nil
+}
+
+// 移除角色的权限，移除拒绝项相当于授予被拒绝的权限，同样需要操作者可以授予
+func (s *RoleService) RemovePermission(grantor *UserPermissions, id uint64, permission string) (*models.Role, error) {
+	if strings.HasPrefix(permission, models.PermDenyPrefix) {
+		if err := grantor.CheckGrantPermissions(models.Permissions{strings.TrimPrefix(permission, models.PermDenyPrefix)}); err != nil {
+			return nil, err
+		}
+	}
+
+	role, err := s.GetRoleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if role.Code == models.RoleSuperuser {
+		return nil, ErrRoleProtected
+	}
+	if !containsPermission(role.Permissions, permission) {
+		return nil, errors.New("角色未拥有该权限")
+	}
+
+	role.RemovePermission(permission)
+	if err := s.roleRepo.Update(role); err != nil {
+		return nil, err
+	}
+	s.invalidatePermissions()
+	return role, nil
+}
+
+// 角色变更会影响所有继承该角色的用户，递增权限版本使全部缓存失效
+func (s *RoleService) invalidatePermissions() {
+	if err := s.permissionService.InvalidateAll(context.Background()); err != nil {
+		logger.GetLogger().Warn("清除权限缓存失败", zap.Error(err))
+	}
+}
+
+// 校验父角色是否存在，并沿父角色链向上检查是否会回到当前角色
+func (s *RoleService) checkParent(role *models.Role) error {
+	role.Parent = nil
+	if role.ParentID == nil {
+		return nil
+	}
+
+	parent, err := s.GetRoleByID(*role.ParentID)
+	if err != nil {
+		if errors.Is(err, ErrRoleNotFound) {
+			return errors.New("父角色不存在")
+		}
+		return err
+	}
+
+	// 新建角色的ID为0，不会出现在已有角色的继承链中
+	visited := make(map[uint64]bool)
+	for ancestor := parent; ancestor != nil; ancestor = ancestor.Parent {
+		if ancestor.ID == role.ID || visited[ancestor.ID] {
+			return ErrRoleCycle
+		}
+		visited[ancestor.ID] = true
+	}
+
+	role.Parent = parent
+	return nil
+}
+
+// 校验权限标识并去除重复项
+func normalizePermissions(permissions models.Permissions) (models.Permissions, error) {
+	normalized := models.Permissions{}
+	for _, permission := range permissions {
+		if err := models.ValidatePermission(permission); err != nil {
+			return nil, err
+		}
+		normalized.AddPermission(permission)
+	}
+	return normalized, nil
+}
+
+// 权限集合是否相同（忽略顺序）
+func samePermissions(a, b models.Permissions) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, permission := range a {
+		if !containsPermission(b, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+// 是否包含指定的权限标识（精确匹配，不处理通配符）
+func containsPermission(permissions models.Permissions, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+// Package services internal/services/role_service_test.go
+package services
+
+import (
+	"context"
+	"errors"
+	"star-go/internal/models"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// 内存角色仓库，仅实现角色服务测试用到的行为
+type memoryRoleRepo struct {
+	roles  map[uint64]*models.Role
+	nextID uint64
+}
+
+func newMemoryRoleRepo(roles ...*models.Role) *memoryRoleRepo {
+	repo := &memoryRoleRepo{roles: make(map[uint64]*models.Role)}
+	for _, role := range roles {
+		_ = repo.Create(role)
+	}
+	return repo
+}
+
+func (r *memoryRoleRepo) Create(role *models.Role) error {
+	r.nextID++
+	role.ID = r.nextID
+	r.roles[role.ID] = r.clone(role)
+	return nil
+}
+
+func (r *memoryRoleRepo) Update(role *models.Role) error {
+	r.roles[role.ID] = r.clone(role)
+	return nil
+}
+
+func (r *memoryRoleRepo) Delete(id uint64) error {
+	delete(r.roles, id)
+	return nil
+}
+
+// 返回角色副本并加载全部祖先角色，与数据库实现一致
+func (r *memoryRoleRepo) FindByID(id uint64) (*models.Role, error) {
+	stored, ok := r.roles[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	role := r.clone(stored)
+	for current := role; current.ParentID != nil; current = current.Parent {
+		parent, ok := r.roles[*current.ParentID]
+		if !ok || parent.ID == id {
+			break
+		}
+		current.Parent = r.clone(parent)
+	}
+	return role, nil
+}
+
+func (r *memoryRoleRepo) FindByCode(code string) (*models.Role, error) {
+	for _, role := range r.roles {
+		if role.Code == code {
+			return r.FindByID(role.ID)
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRoleRepo) FindByName(name string) (*models.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return r.clone(role), nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRoleRepo) FindByIDs(ids []uint64) ([]*models.Role, error) {
+	var roles []*models.Role
+	for _, id := range ids {
+		if role, ok := r.roles[id]; ok {
+			roles = append(roles, r.clone(role))
+		}
+	}
+	return roles, nil
+}
+
+func (r *memoryRoleRepo) List(page, size int, query string) ([]*models.Role, int64, error) {
+	return nil, int64(len(r.roles)), nil
+}
+
+func (r *memoryRoleRepo) CountUsers(roleID uint64) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryRoleRepo) CountChildren(roleID uint64) (int64, error) {
+	var count int64
+	for _, role := range r.roles {
+		if role.ParentID != nil && *role.ParentID == roleID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryRoleRepo) clone(role *models.Role) *models.Role {
+	copied := *role
+	copied.Parent = nil
+	copied.Permissions = append(models.Permissions{}, role.Permissions...)
+	return &copied
+}
+
+// 不做任何操作的权限服务
+type nopPermissionService struct{}
+
+func (nopPermissionService) Resolve(ctx context.Context, userID uint64) (*UserPermissions, error) {
+	return nil, ErrPermissionUserNotFound
+}
+
+func (nopPermissionService) InvalidateUser(ctx context.Context, userID uint64) error {
+	return nil
+}
+
+func (nopPermissionService) InvalidateAll(ctx context.Context) error {
+	return nil
+}
+
+// 创建包含内置角色层级的角色服务：访客 ⊂ 普通用户 ⊂ 管理员 ⊂ 超级管理员
+func newTestRoleService(t *testing.T) (*RoleService, map[string]*models.Role) {
+	t.Helper()
+	guest := &models.Role{Name: "访客", Code: models.RoleGuest, Permissions: models.Permissions{"user:view", "content:view"}}
+	repo := newMemoryRoleRepo(guest)
+	user := &models.Role{Name: "普通用户", Code: models.RoleUser, Permissions: models.Permissions{"user:edit"}, ParentID: &guest.ID}
+	_ = repo.Create(user)
+	admin := &models.Role{
+		Name:        "管理员",
+		Code:        models.RoleAdmin,
+		Permissions: models.Permissions{"user:*", "content:*", "role:manage", "system:config", "system:log"},
+		ParentID:    &user.ID,
+	}
+	_ = repo.Create(admin)
+	superuser := &models.Role{Name: "超级管理员", Code: models.RoleSuperuser, Permissions: models.Permissions{"*"}, ParentID: &admin.ID}
+	_ = repo.Create(superuser)
+
+	service := &RoleService{roleRepo: repo, permissionService: nopPermissionService{}}
+	return service, map[string]*models.Role{
+		models.RoleGuest:     guest,
+		models.RoleUser:      user,
+		models.RoleAdmin:     admin,
+		models.RoleSuperuser: superuser,
+	}
+}
+
+// 解析指定角色的用户权限
+func grantorWithRole(t *testing.T, service *RoleService, role *models.Role) *UserPermissions {
+	t.Helper()
+	full, err := service.roleRepo.FindByID(role.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &UserPermissions{UserID: 1, Roles: []string{full.Code}, Permissions: full.EffectivePermissions()}
+}
+
+func isGrantError(err error) bool {
+	return errors.Is(err, ErrPermissionNotHeld) || errors.Is(err, ErrSuperuserOnly)
+}
+
+func TestRoleServiceAddPermissionRequiresHeldPermission(t *testing.T) {
+	service, roles := newTestRoleService(t)
+	admin := grantorWithRole(t, service, roles[models.RoleAdmin])
+	superuser := grantorWithRole(t, service, roles[models.RoleSuperuser])
+
+	tests := []struct {
+		name       string
+		grantor    *UserPermissions
+		roleID     uint64
+		permission string
+		wantErr    bool
+	}{
+		{"管理员给自己的角色授予全部权限", admin, roles[models.RoleAdmin].ID, "*", true},
+		{"管理员授予未拥有的权限", admin, roles[models.RoleUser].ID, "system:backup", true},
+		{"管理员授予覆盖未拥有权限的通配", admin, roles[models.RoleUser].ID, "system:*", true},
+		{"管理员授予自己拥有的权限", admin, roles[models.RoleUser].ID, "user:list", false},
+		{"管理员授予拒绝项", admin, roles[models.RoleUser].ID, "!system:backup", false},
+		{"超级管理员授予全部权限", superuser, roles[models.RoleAdmin].ID, "*", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.AddPermission(tt.grantor, tt.roleID, tt.permission)
+			if tt.wantErr && !isGrantError(err) {
+				t.Errorf("err = %v, want 授权错误", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("err = %v, want nil", err)
+			}
+		})
+	}
+
+	role, err := service.GetRoleByID(roles[models.RoleAdmin].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsPermission(role.Permissions, models.PermAll) {
+		t.Errorf("超级管理员授予全部权限后管理员角色的权限 = %v", role.Permissions)
+	}
+	if containsPermission(role.Permissions, "system:backup") {
+		t.Error("被拒绝的授权不应写入角色")
+	}
+}
+
+func TestRoleServiceRejectsSuperuserParent(t *testing.T) {
+	service, roles := newTestRoleService(t)
+	admin := grantorWithRole(t, service, roles[models.RoleAdmin])
+	superuserID := roles[models.RoleSuperuser].ID
+
+	// 创建继承超级管理员的角色
+	err := service.CreateRole(admin, &models.Role{Name: "影子管理员", Code: "shadow", ParentID: &superuserID})
+	if !errors.Is(err, ErrSuperuserOnly) {
+		t.Errorf("CreateRole err = %v, want %v", err, ErrSuperuserOnly)
+	}
+
+	// 把已有角色的父角色改为超级管理员
+	role := &models.Role{Name: "编辑", Code: "editor", Permissions: models.Permissions{"content:edit"}}
+	if err := service.CreateRole(admin, role); err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	update := &models.Role{Name: role.Name, Code: role.Code, Permissions: role.Permissions, ParentID: &superuserID}
+	update.ID = role.ID
+	if err := service.UpdateRole(admin, update); !errors.Is(err, ErrSuperuserOnly) {
+		t.Errorf("UpdateRole err = %v, want %v", err, ErrSuperuserOnly)
+	}
+
+	// 角色自身权限超出管理员权限
+	err = service.CreateRole(admin, &models.Role{Name: "备份员", Code: "backup", Permissions: models.Permissions{"system:backup"}})
+	if !errors.Is(err, ErrPermissionNotHeld) {
+		t.Errorf("CreateRole err = %v, want %v", err, ErrPermissionNotHeld)
+	}
+
+	// 超级管理员可以设置任意父角色
+	superuser := grantorWithRole(t, service, roles[models.RoleSuperuser])
+	if err := service.UpdateRole(superuser, update); err != nil {
+		t.Errorf("超级管理员 UpdateRole err = %v, want nil", err)
+	}
+}
+
+func TestRoleServiceRemoveDenyRequiresHeldPermission(t *testing.T) {
+	service, roles := newTestRoleService(t)
+	admin := grantorWithRole(t, service, roles[models.RoleAdmin])
+
+	role := &models.Role{Name: "运维", Code: "ops", Permissions: models.Permissions{"system:config", "!system:log"}}
+	if err := service.CreateRole(admin, role); err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	if _, err := service.RemovePermission(admin, role.ID, "!system:log"); err != nil {
+		t.Errorf("移除自己拥有权限的拒绝项 err = %v, want nil", err)
+	}
+
+	// 移除拒绝项等同于授予被拒绝的权限
+	guarded := &models.Role{Name: "审计", Code: "audit", Permissions: models.Permissions{"!system:backup"}}
+	if err := service.CreateRole(admin, guarded); err != nil {
+		t.Fatalf("创建角色失败: %v", err)
+	}
+	if _, err := service.RemovePermission(admin, guarded.ID, "!system:backup"); !errors.Is(err, ErrPermissionNotHeld) {
+		t.Errorf("移除未拥有权限的拒绝项 err = %v, want %v", err, ErrPermissionNotHeld)
+	}
+}
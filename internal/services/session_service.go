@@ -0,0 +1,191 @@
+// Package services internal/services/session_service.go
+package services
+
+import (
+	"context"
+	"errors"
+	"star-go/internal/models"
+	"star-go/internal/repository"
+	"star-go/pkg/cache"
+	"star-go/pkg/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ClientInfo 发起请求的客户端信息
+type ClientInfo struct {
+	IP        string // 客户端IP
+	UserAgent string // 客户端User-Agent
+}
+
+// ISessionService 会话服务接口
+type ISessionService interface {
+	CreateSession(ctx context.Context, userID uint64, client *ClientInfo) (*models.UserSession, error)
+	TouchSession(ctx context.Context, sessionID string, client *ClientInfo) error
+	ListSessions(ctx context.Context, userID uint64) ([]*models.UserSession, error)
+	RevokeSession(ctx context.Context, userID uint64, sessionID string) error
+	RevokeAllSessions(ctx context.Context, userID uint64) error
+}
+
+// SessionService 会话服务实现
+type SessionService struct {
+	sessionRepo repository.ISessionRepository
+	tokenStore  cache.RefreshTokenStore
+	denylist    cache.TokenDenylist
+}
+
+// NewSessionService 创建会话服务实例
+func NewSessionService() ISessionService {
+	return &SessionService{
+		sessionRepo: repository.NewSessionRepository(),
+		tokenStore:  cache.NewRefreshTokenStore(cache.GetCache(), utils.RefreshTokenTTL()),
+		denylist:    cache.NewTokenDenylist(cache.GetCache(), utils.RefreshTokenTTL()),
+	}
+}
+
+// CreateSession 登录时创建会话
+func (s *SessionService) CreateSession(ctx context.Context, userID uint64, client *ClientInfo) (*models.UserSession, error) {
+	now := time.Now()
+	session := &models.UserSession{
+		UserID:     userID,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(utils.RefreshTokenTTL()),
+	}
+	if client != nil {
+		session.IP = client.IP
+		session.UserAgent = truncate(client.UserAgent, 255)
+		session.Device = describeDevice(client.UserAgent)
+	}
+
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// TouchSession 刷新令牌时更新会话的活跃时间和过期时间
+func (s *SessionService) TouchSession(ctx context.Context, sessionID string, client *ClientInfo) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("会话不存在")
+		}
+		return err
+	}
+	if !session.IsActive() {
+		return errors.New("会话已失效")
+	}
+
+	now := time.Now()
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(utils.RefreshTokenTTL())
+	if client != nil && client.IP != "" {
+		session.IP = client.IP
+	}
+	return s.sessionRepo.Update(session)
+}
+
+// ListSessions 获取用户的有效会话列表
+func (s *SessionService) ListSessions(ctx context.Context, userID uint64) ([]*models.UserSession, error) {
+	return s.sessionRepo.ListActiveByUserID(userID)
+}
+
+// RevokeSession 撤销用户的指定会话
+func (s *SessionService) RevokeSession(ctx context.Context, userID uint64, sessionID string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("会话不存在")
+		}
+		return err
+	}
+
+	// 不允许撤销其他用户的会话
+	if session.UserID != userID {
+		return errors.New("会话不存在")
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	if err := s.sessionRepo.Update(session); err != nil {
+		return err
+	}
+	return s.revokeTokens(ctx, session.ID)
+}
+
+// RevokeAllSessions 撤销用户的所有会话
+func (s *SessionService) RevokeAllSessions(ctx context.Context, userID uint64) error {
+	ids, err := s.sessionRepo.RevokeAllByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := s.revokeTokens(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 撤销会话绑定的刷新令牌族以及已签发的访问令牌
+func (s *SessionService) revokeTokens(ctx context.Context, sessionID string) error {
+	if err := s.tokenStore.RevokeFamily(ctx, sessionID); err != nil {
+		return err
+	}
+	return s.denylist.RevokeSession(ctx, sessionID, utils.AccessTokenTTL())
+}
+
+// 根据User-Agent粗略识别设备类型
+func describeDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+
+	var platform string
+	switch {
+	case strings.Contains(ua, "iphone"), strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"), strings.Contains(ua, "macintosh"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	default:
+		platform = "未知设备"
+	}
+
+	var client string
+	switch {
+	case strings.Contains(ua, "micromessenger"):
+		client = "微信"
+	case strings.Contains(ua, "edg/"):
+		client = "Edge"
+	case strings.Contains(ua, "chrome/"):
+		client = "Chrome"
+	case strings.Contains(ua, "firefox/"):
+		client = "Firefox"
+	case strings.Contains(ua, "safari/"):
+		client = "Safari"
+	case strings.Contains(ua, "curl/"), strings.Contains(ua, "postman"):
+		client = "API客户端"
+	}
+
+	if client == "" {
+		return platform
+	}
+	return platform + " " + client
+}
+
+// 截断字符串到指定长度
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	return s[:size]
+}
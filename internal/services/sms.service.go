@@ -2,9 +2,15 @@ package services
 
 import (
 	"context"
-	"fmt"
-	"math/rand/v2"
+	"crypto/rand"
+	"math/big"
 	"star-go/pkg/cache"
+	"star-go/pkg/logger"
+	"star-go/pkg/phonenumber"
+	"star-go/pkg/sms"
+	"strconv"
+
+	"go.uber.org/zap"
 )
 
 // 业务类型常量
@@ -16,49 +22,98 @@ const (
 )
 
 type SMSService interface {
-	Send(ctx context.Context, biz, phone string) error
+	// Send 发送验证码，clientIP用于按IP限制发送次数，超出限制时返回 *SMSRateLimitError
+	Send(ctx context.Context, biz, phone, clientIP string) error
+	// Deliver 生成并发送验证码，不检查发送频率，调用方需已通过 Limiter 占用配额
+	Deliver(ctx context.Context, biz, phone string) error
+	// Limiter 获取发送频率限制器
+	Limiter() SMSLimiter
 	Verify(ctx context.Context, biz, phone, code string) (bool, error)
 }
 
 type smsService struct {
-	cache cache.SMSCodeCache
-
-	//添加一个真正发送短信的客户端
-	// smsClient SMSClient
+	cache     cache.SMSCodeCache
+	smsClient sms.Client // 短信发送客户端
+	limiter   SMSLimiter // 发送频率限制
 }
 
 func NewSMSService() SMSService {
 	return &smsService{
-		cache: cache.NewRedisCodeCache(),
+		cache:     cache.NewSMSCodeCache(),
+		smsClient: sms.GetClient(),
+		limiter:   NewSMSLimiter(),
 	}
 }
 
-func (s *smsService) Send(ctx context.Context, biz, phone string) error {
-	code := generateCode()
+func (s *smsService) Send(ctx context.Context, biz, phone, clientIP string) error {
+	phone, err := phonenumber.Normalize(phone)
+	if err != nil {
+		return err
+	}
+
+	// 检查发送频率，防止短信轰炸
+	if err := s.limiter.Acquire(ctx, biz, phone, clientIP); err != nil {
+		return err
+	}
+
+	return s.Deliver(ctx, biz, phone)
+}
+
+func (s *smsService) Deliver(ctx context.Context, biz, phone string) error {
+	phone, err := phonenumber.Normalize(phone)
+	if err != nil {
+		return err
+	}
+
+	code := generateCode(cache.SMSCodeLength())
 
 	//将代码存到缓存中
-	err := s.cache.Set(ctx, biz, phone, code)
+	err = s.cache.Set(ctx, biz, phone, code)
+	if err != nil {
+		_ = s.limiter.Release(ctx, biz, phone)
+		return err
+	}
+	// 按业务模板渲染并发送短信
+	err = s.smsClient.Send(ctx, &sms.Message{
+		Phone: phone,
+		Biz:   biz,
+		Content: sms.RenderTemplate(biz, map[string]string{
+			"code":    code,
+			"minutes": strconv.Itoa(int(cache.SMSCodeTTL().Minutes())),
+		}),
+	})
 	if err != nil {
+		logger.GetLogger().Error("发送短信失败", zap.Error(err), zap.String("phone", phone), zap.String("biz", biz))
+		// 发送失败时清除验证码，允许立即重试
+		_ = s.cache.Remove(ctx, biz, phone)
+		_ = s.limiter.Release(ctx, biz, phone)
 		return err
 	}
-	// 调用短信发送SDK发送短信
-	// err = s.smsClient.Send(phone, fmt.Sprintf("您的验证码是: %s, 有效期5分钟", code))
-	// if err != nil {
-	//     s.logger.Error("Failed to send SMS", "error", err, "phone", phone, "biz", biz)
-	//     return "", err
-	// }
-	fmt.Printf("sms:%s", code)
 
 	return nil
 }
 
+func (s *smsService) Limiter() SMSLimiter {
+	return s.limiter
+}
+
 func (s *smsService) Verify(ctx context.Context, biz, phone, code string) (bool, error) {
+	phone, err := phonenumber.Normalize(phone)
+	if err != nil {
+		return false, err
+	}
 	return s.cache.Verify(ctx, biz, phone, code)
 }
 
-// 生成一个随机数
-func generateCode() string {
-	num := rand.IntN(1000000)
-	//不够6位数的，加上前导0
-	return fmt.Sprintf("%06d", num)
+// 生成指定长度的数字验证码，使用密码学安全的随机数，短信、找回密码和邮箱验证共用
+func generateCode(length int) string {
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			panic(err)
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code)
 }
@@ -0,0 +1,128 @@
+// Package services internal/services/sms_limiter.go
+package services
+
+import (
+	"context"
+	"fmt"
+	"star-go/pkg/cache"
+	"star-go/pkg/config"
+	"time"
+)
+
+// 短信发送限制默认配置
+const (
+	defaultSMSCooldown      = time.Minute
+	defaultSMSPhoneDailyCap = 10
+	defaultSMSIPDailyCap    = 50
+)
+
+// SMSRateLimitError 短信发送频率受限错误
+type SMSRateLimitError struct {
+	Reason     string        // 受限原因
+	RetryAfter time.Duration // 距离可再次发送的时间
+}
+
+func (e *SMSRateLimitError) Error() string {
+	return fmt.Sprintf("%s，请%d秒后重试", e.Reason, e.RetryAfter/time.Second)
+}
+
+// SMSLimiter 短信发送限制器接口
+type SMSLimiter interface {
+	// Acquire 检查并占用一次发送配额，受限时返回 *SMSRateLimitError
+	Acquire(ctx context.Context, biz, phone, clientIP string) error
+
+	// Release 发送失败时释放冷却时间，允许立即重试
+	Release(ctx context.Context, biz, phone string) error
+}
+
+// 基于通用缓存计数器的限制器实现
+type smsLimiter struct {
+	cache         cache.Cache
+	cooldown      time.Duration // 同一业务同一手机号的重发间隔
+	phoneDailyCap int           // 每个手机号每日发送上限
+	ipDailyCap    int           // 每个IP每日发送上限
+}
+
+// NewSMSLimiter 根据配置创建短信发送限制器
+func NewSMSLimiter() SMSLimiter {
+	cfg := config.GetConfig().SMS.Limit
+
+	limiter := &smsLimiter{
+		cache:         cache.GetCache(),
+		cooldown:      cfg.Cooldown * time.Second,
+		phoneDailyCap: cfg.PhoneDaily,
+		ipDailyCap:    cfg.IPDaily,
+	}
+	if limiter.cooldown <= 0 {
+		limiter.cooldown = defaultSMSCooldown
+	}
+	if limiter.phoneDailyCap <= 0 {
+		limiter.phoneDailyCap = defaultSMSPhoneDailyCap
+	}
+	if limiter.ipDailyCap <= 0 {
+		limiter.ipDailyCap = defaultSMSIPDailyCap
+	}
+	return limiter
+}
+
+// Acquire 依次检查冷却时间、手机号日配额和IP日配额
+func (l *smsLimiter) Acquire(ctx context.Context, biz, phone, clientIP string) error {
+	// 冷却键首次计数时创建，冷却期内的重复请求直接拒绝
+	cooldownKey := generateSMSCooldownKey(biz, phone)
+	count, err := l.cache.Incr(ctx, cooldownKey, l.cooldown)
+	if err != nil {
+		return err
+	}
+	if count > 1 {
+		retryAfter, err := l.cache.TTL(ctx, cooldownKey)
+		if err != nil {
+			retryAfter = l.cooldown
+		}
+		return &SMSRateLimitError{Reason: "验证码发送过于频繁", RetryAfter: retryAfter}
+	}
+
+	// 日配额按自然日计数，次日零点自动失效
+	now := time.Now()
+	untilTomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location()).Sub(now)
+	day := now.Format("20060102")
+
+	count, err = l.cache.Incr(ctx, generateSMSPhoneDailyKey(day, phone), untilTomorrow)
+	if err != nil {
+		return err
+	}
+	if count > int64(l.phoneDailyCap) {
+		return &SMSRateLimitError{Reason: "该手机号今日验证码发送次数已达上限", RetryAfter: untilTomorrow}
+	}
+
+	if clientIP != "" {
+		count, err = l.cache.Incr(ctx, generateSMSIPDailyKey(day, clientIP), untilTomorrow)
+		if err != nil {
+			return err
+		}
+		if count > int64(l.ipDailyCap) {
+			return &SMSRateLimitError{Reason: "当前网络今日验证码发送次数已达上限", RetryAfter: untilTomorrow}
+		}
+	}
+
+	return nil
+}
+
+// Release 释放冷却时间，已占用的日配额不退还
+func (l *smsLimiter) Release(ctx context.Context, biz, phone string) error {
+	return l.cache.Delete(ctx, generateSMSCooldownKey(biz, phone))
+}
+
+// 生成发送冷却键
+func generateSMSCooldownKey(biz, phone string) string {
+	return fmt.Sprintf("sms:limit:cooldown:%s:%s", biz, phone)
+}
+
+// 生成手机号日配额键
+func generateSMSPhoneDailyKey(day, phone string) string {
+	return fmt.Sprintf("sms:limit:phone:%s:%s", day, phone)
+}
+
+// 生成IP日配额键
+func generateSMSIPDailyKey(day, clientIP string) string {
+	return fmt.Sprintf("sms:limit:ip:%s:%s", day, clientIP)
+}
@@ -2,9 +2,14 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"star-go/internal/models"
 	"star-go/internal/repository"
+	"star-go/pkg/logger"
+
+	"go.uber.org/zap"
 )
 
 // IUserService 用户服务接口
@@ -15,19 +20,27 @@ type IUserService interface {
 	ListUsers(page, pageSize int, search string) ([]*models.User, int64, error)
 	CreateUser(user *models.User) error
 	UpdateUser(user *models.User) error
+	UpdateUserWithRoles(grantor *UserPermissions, user *models.User, roles []*models.Role) error
 	DeleteUser(id uint64) error
 	HasPermission(userID uint64, permission string) (bool, error)
+	FindRoles(roleIDs []uint64) ([]*models.Role, error)
+	FindAssignableRoles(grantor *UserPermissions, roleIDs []uint64) ([]*models.Role, error)
+	SetRoles(userID uint64, roleIDs []uint64) error
 }
 
 // UserService 用户服务实现
 type UserService struct {
-	userRepo repository.IUserRepository
+	userRepo          repository.IUserRepository
+	roleRepo          repository.IRoleRepository
+	permissionService IPermissionService
 }
 
 // NewUserService 创建用户服务实例
 func NewUserService() IUserService {
 	return &UserService{
-		userRepo: repository.NewUserRepository(),
+		userRepo:          repository.NewUserRepository(),
+		roleRepo:          repository.NewRoleRepository(),
+		permissionService: NewPermissionService(),
 	}
 }
 
@@ -71,6 +84,40 @@ func (s *UserService) CreateUser(user *models.User) error {
 
 // 更新用户
 func (s *UserService) UpdateUser(user *models.User) error {
+	if err := s.checkUpdateConflicts(user); err != nil {
+		return err
+	}
+
+	// 更新用户
+	return s.userRepo.Update(user)
+}
+
+// 更新用户信息并替换全部角色，两者在同一事务中提交，角色需先通过 FindAssignableRoles 校验；
+// 用户现有的角色也必须是操作者可以授予的，避免修改权限更高的用户
+func (s *UserService) UpdateUserWithRoles(grantor *UserPermissions, user *models.User, roles []*models.Role) error {
+	existingUser, err := s.userRepo.FindByID(user.ID)
+	if err != nil {
+		return err
+	}
+	for _, role := range existingUser.Roles {
+		if err := grantor.CheckGrantRole(role); err != nil {
+			return err
+		}
+	}
+
+	if err := s.checkUpdateConflicts(user); err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdateWithRoles(user, roles); err != nil {
+		return err
+	}
+	s.invalidatePermissions(user.ID)
+	return nil
+}
+
+// 检查待更新的用户是否存在，以及用户名和邮箱是否与其他用户冲突
+func (s *UserService) checkUpdateConflicts(user *models.User) error {
 	// 检查用户是否存在
 	existingUser, err := s.userRepo.FindByID(user.ID)
 	if err != nil {
@@ -95,9 +142,7 @@ func (s *UserService) UpdateUser(user *models.User) error {
 			return errors.New("邮箱已存在")
 		}
 	}
-
-	// 更新用户
-	return s.userRepo.Update(user)
+	return nil
 }
 
 // 删除用户
@@ -112,7 +157,11 @@ func (s *UserService) DeleteUser(id uint64) error {
 	}
 
 	// 删除用户
-	return s.userRepo.Delete(id)
+	if err := s.userRepo.Delete(id); err != nil {
+		return err
+	}
+	s.invalidatePermissions(id)
+	return nil
 }
 
 // 检查用户是否拥有指定权限
@@ -129,3 +178,67 @@ func (s *UserService) HasPermission(userID uint64, permission string) (bool, err
 	// 检查用户是否拥有指定权限
 	return user.HasPermission(permission), nil
 }
+
+// 根据ID查找角色，任意一个角色不存在时返回错误
+func (s *UserService) FindRoles(roleIDs []uint64) ([]*models.Role, error) {
+	roles, err := s.roleRepo.FindByIDs(roleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[uint64]bool, len(roles))
+	for _, role := range roles {
+		found[role.ID] = true
+	}
+	for _, id := range roleIDs {
+		if !found[id] {
+			return nil, fmt.Errorf("角色不存在: %d", id)
+		}
+	}
+	return roles, nil
+}
+
+// 根据ID查找要分配给用户的角色，每个角色包含继承在内的有效权限都必须是操作者可以授予的
+func (s *UserService) FindAssignableRoles(grantor *UserPermissions, roleIDs []uint64) ([]*models.Role, error) {
+	roles, err := s.FindRoles(roleIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 批量查询的角色未加载父角色，逐个加载完整的继承链后再校验
+	for _, role := range roles {
+		full, err := s.roleRepo.FindByID(role.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := grantor.CheckGrantRole(full); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// 替换用户的全部角色
+func (s *UserService) SetRoles(userID uint64, roleIDs []uint64) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+
+	roles, err := s.FindRoles(roleIDs)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.ReplaceRoles(user, roles); err != nil {
+		return err
+	}
+	s.invalidatePermissions(userID)
+	return nil
+}
+
+// 使用户的权限缓存失效，失败时缓存会在过期后自动刷新
+func (s *UserService) invalidatePermissions(userID uint64) {
+	if err := s.permissionService.InvalidateUser(context.Background(), userID); err != nil {
+		logger.GetLogger().Warn("清除用户权限缓存失败", zap.Uint64("user_id", userID), zap.Error(err))
+	}
+}
@@ -18,14 +18,24 @@ func main() {
 		log.Fatalf("初始化日志系统失败: %v", err)
 	}
 
+	// 初始化JWT签名密钥
+	if err := core.InitJWT(); err != nil {
+		log.Fatalf("初始化JWT密钥失败: %v", err)
+	}
+
 	// 初始化数据库连接
 	if err := core.InitDatabase(); err != nil {
 		log.Fatalf("初始化数据库失败: %v", err)
 	}
-	// 初始化缓存 目前不用先预留
-	// if err := core.InitCache(); err != nil {
-	// 	log.Fatalf("初始化缓存失败: %v", err)
-	// }
+	// 初始化缓存 - 刷新令牌等服务端状态依赖缓存
+	if err := core.InitCache(); err != nil {
+		log.Fatalf("初始化缓存失败: %v", err)
+	}
+
+	// 初始化短信客户端
+	if err := core.InitSMS(); err != nil {
+		log.Fatalf("初始化短信客户端失败: %v", err)
+	}
 
 	// 初始化Gin引擎
 	router := core.InitGin()
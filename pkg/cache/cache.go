@@ -3,6 +3,7 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"star-go/pkg/config"
 	"star-go/pkg/logger"
@@ -28,6 +29,12 @@ type Cache interface {
 	// Expire 设置过期时间
 	Expire(ctx context.Context, key string, expiration time.Duration) error
 
+	// Incr 计数器加一并返回新值，键不存在时创建并设置过期时间
+	Incr(ctx context.Context, key string, expiration time.Duration) (int64, error)
+
+	// TTL 获取键的剩余过期时间，永不过期时返回0
+	TTL(ctx context.Context, key string) (time.Duration, error)
+
 	// FlushDB 清空当前数据库
 	FlushDB(ctx context.Context) error
 
@@ -38,6 +45,9 @@ type Cache interface {
 	GetClient() interface{}
 }
 
+// ErrCacheMiss 缓存键不存在或已过期
+var ErrCacheMiss = errors.New("缓存键不存在")
+
 // 全局缓存实例
 var globalCache Cache
 
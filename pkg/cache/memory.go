@@ -171,7 +171,7 @@ func (m *memoryCache) Get(ctx context.Context, key string, dest interface{}) err
 	item, found := m.items[prefixedKey]
 	if !found {
 		m.mu.RUnlock()
-		err := fmt.Errorf("缓存键不存在: %s", key)
+		err := fmt.Errorf("%w: %s", ErrCacheMiss, key)
 		m.logOperation("GET", key, err)
 		return err
 	}
@@ -182,7 +182,7 @@ func (m *memoryCache) Get(ctx context.Context, key string, dest interface{}) err
 		m.mu.Lock()
 		delete(m.items, prefixedKey)
 		m.mu.Unlock()
-		err := fmt.Errorf("缓存键已过期: %s", key)
+		err := fmt.Errorf("%w(已过期): %s", ErrCacheMiss, key)
 		m.logOperation("GET", key, err)
 		return err
 	}
@@ -266,6 +266,57 @@ func (m *memoryCache) Expire(ctx context.Context, key string, expiration time.Du
 	return nil
 }
 
+// Incr 计数器加一
+func (m *memoryCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	prefixedKey := m.prefixKey(key)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 键不存在或已过期时从0开始计数，并设置过期时间
+	item, found := m.items[prefixedKey]
+	var count int64
+	if found && !item.Expired() {
+		if err := json.Unmarshal(item.Value, &count); err != nil {
+			m.logOperation("INCR", key, err)
+			return 0, fmt.Errorf("缓存值不是整数: %w", err)
+		}
+	} else {
+		item = cacheItem{}
+		if expiration > 0 {
+			item.Expiration = time.Now().Add(expiration).UnixNano()
+		}
+	}
+
+	count++
+	item.Value, _ = json.Marshal(count)
+	m.items[prefixedKey] = item
+
+	m.logOperation("INCR", key, nil)
+	return count, nil
+}
+
+// TTL 获取剩余过期时间
+func (m *memoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	prefixedKey := m.prefixKey(key)
+
+	m.mu.RLock()
+	item, found := m.items[prefixedKey]
+	m.mu.RUnlock()
+
+	if !found || item.Expired() {
+		err := fmt.Errorf("%w: %s", ErrCacheMiss, key)
+		m.logOperation("TTL", key, err)
+		return 0, err
+	}
+
+	m.logOperation("TTL", key, nil)
+	if item.Expiration == 0 {
+		return 0, nil
+	}
+	return time.Duration(item.Expiration - time.Now().UnixNano()), nil
+}
+
 // FlushDB 清空当前数据库
 func (m *memoryCache) FlushDB(ctx context.Context) error {
 	m.mu.Lock()
@@ -123,7 +123,7 @@ func (r *redisCache) Get(ctx context.Context, key string, dest interface{}) erro
 
 	if err != nil {
 		if err == redis.Nil {
-			return fmt.Errorf("缓存键不存在: %s", key)
+			return fmt.Errorf("%w: %s", ErrCacheMiss, key)
 		}
 		return fmt.Errorf("获取缓存失败: %w", err)
 	}
@@ -181,6 +181,46 @@ func (r *redisCache) Expire(ctx context.Context, key string, expiration time.Dur
 	return nil
 }
 
+// Incr 计数器加一
+func (r *redisCache) Incr(ctx context.Context, key string, expiration time.Duration) (int64, error) {
+	prefixedKey := r.prefixKey(key)
+
+	// 计数并在首次创建时设置过期时间
+	count, err := r.client.Incr(ctx, prefixedKey).Result()
+	if err == nil && count == 1 && expiration > 0 {
+		err = r.client.Expire(ctx, prefixedKey, expiration).Err()
+	}
+	r.logOperation("INCR", key, err)
+
+	if err != nil {
+		return 0, fmt.Errorf("缓存计数失败: %w", err)
+	}
+
+	return count, nil
+}
+
+// TTL 获取剩余过期时间
+func (r *redisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	prefixedKey := r.prefixKey(key)
+
+	ttl, err := r.client.PTTL(ctx, prefixedKey).Result()
+	r.logOperation("TTL", key, err)
+
+	if err != nil {
+		return 0, fmt.Errorf("获取缓存过期时间失败: %w", err)
+	}
+
+	// -2 表示键不存在，-1 表示永不过期
+	if ttl == -2 {
+		return 0, fmt.Errorf("%w: %s", ErrCacheMiss, key)
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
 // FlushDB 清空当前数据库
 func (r *redisCache) FlushDB(ctx context.Context) error {
 	// 清空数据库
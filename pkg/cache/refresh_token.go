@@ -0,0 +1,108 @@
+// Package cache pkg/cache/refresh_token.go
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// RefreshTokenRecord 刷新令牌的服务端记录
+type RefreshTokenRecord struct {
+	UserID    uint64    `json:"user_id"`    // 所属用户ID
+	FamilyID  string    `json:"family_id"`  // 令牌族ID，同一次登录轮换出的令牌共享
+	ExpiresAt time.Time `json:"expires_at"` // 过期时间
+}
+
+// RefreshTokenStore 刷新令牌存储接口
+type RefreshTokenStore interface {
+	// Save 保存刷新令牌记录（令牌以哈希形式存储）
+	Save(ctx context.Context, token string, record *RefreshTokenRecord) error
+
+	// Get 获取刷新令牌记录，不存在时返回 ErrCacheMiss
+	Get(ctx context.Context, token string) (*RefreshTokenRecord, error)
+
+	// Claim 原子地占用刷新令牌，只有第一次调用返回 true，之后的调用视为重放；
+	// 占用标记保留到令牌过期以便识别重放
+	Claim(ctx context.Context, token string, record *RefreshTokenRecord) (bool, error)
+
+	// RevokeFamily 撤销整个令牌族
+	RevokeFamily(ctx context.Context, familyID string) error
+
+	// IsFamilyRevoked 检查令牌族是否已被撤销
+	IsFamilyRevoked(ctx context.Context, familyID string) (bool, error)
+}
+
+// 基于通用缓存的刷新令牌存储实现
+type refreshTokenStore struct {
+	cache Cache
+	ttl   time.Duration // 令牌族撤销标记的保留时间
+}
+
+// NewRefreshTokenStore 创建刷新令牌存储
+func NewRefreshTokenStore(cache Cache, ttl time.Duration) RefreshTokenStore {
+	return &refreshTokenStore{
+		cache: cache,
+		ttl:   ttl,
+	}
+}
+
+// Save 保存刷新令牌记录
+func (s *refreshTokenStore) Save(ctx context.Context, token string, record *RefreshTokenRecord) error {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("刷新令牌已过期")
+	}
+	return s.cache.Set(ctx, generateRefreshTokenKey(token), record, ttl)
+}
+
+// Get 获取刷新令牌记录
+func (s *refreshTokenStore) Get(ctx context.Context, token string) (*RefreshTokenRecord, error) {
+	var record RefreshTokenRecord
+	if err := s.cache.Get(ctx, generateRefreshTokenKey(token), &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Claim 原子地占用刷新令牌，基于自增计数，并发请求中只有计数为1的请求占用成功
+func (s *refreshTokenStore) Claim(ctx context.Context, token string, record *RefreshTokenRecord) (bool, error) {
+	ttl := time.Until(record.ExpiresAt)
+	if ttl <= 0 {
+		return false, fmt.Errorf("刷新令牌已过期")
+	}
+	count, err := s.cache.Incr(ctx, generateRefreshUsedKey(token), ttl)
+	if err != nil {
+		return false, err
+	}
+	return count == 1, nil
+}
+
+// RevokeFamily 撤销令牌族
+func (s *refreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	return s.cache.Set(ctx, generateRefreshFamilyKey(familyID), true, s.ttl)
+}
+
+// IsFamilyRevoked 检查令牌族是否已被撤销
+func (s *refreshTokenStore) IsFamilyRevoked(ctx context.Context, familyID string) (bool, error) {
+	return s.cache.Exists(ctx, generateRefreshFamilyKey(familyID))
+}
+
+// 生成刷新令牌缓存键，只保存令牌的SHA-256哈希
+func generateRefreshTokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("refresh:token:%s", hex.EncodeToString(sum[:]))
+}
+
+// 生成刷新令牌占用标记键
+func generateRefreshUsedKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return fmt.Sprintf("refresh:token:used:%s", hex.EncodeToString(sum[:]))
+}
+
+// 生成令牌族撤销标记键
+func generateRefreshFamilyKey(familyID string) string {
+	return fmt.Sprintf("refresh:family:revoked:%s", familyID)
+}
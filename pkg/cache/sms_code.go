@@ -5,9 +5,32 @@ import (
 	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
+	"star-go/pkg/config"
+	"star-go/pkg/phonenumber"
+	"sync"
 	"time"
 )
 
+// 验证码默认配置
+const (
+	defaultSMSCodeTTL     = 5 * time.Minute
+	defaultSMSCodeLength  = 6
+	defaultSMSMaxAttempts = 3
+)
+
+// 验证码校验错误
+var (
+	ErrCodeExpired     = errors.New("验证码已失效，请重新获取")
+	ErrTooManyAttempts = errors.New("验证次数过多，请重新获取")
+	ErrCodeMismatch    = errors.New("验证码不匹配，请重新输入")
+)
+
+// 进程内共享的内存验证码缓存，保证所有短信服务实例读写同一份验证码
+var (
+	memoryCodeCache     *MemoryCodeCache
+	memoryCodeCacheOnce sync.Once
+)
+
 type SMSCodeCache interface {
 	Set(ctx context.Context, biz, phone, code string) error
 	Verify(ctx context.Context, biz, phone, code string) (bool, error)
@@ -16,21 +39,58 @@ type SMSCodeCache interface {
 
 type RedisCodeCache struct {
 	client      *redis.Client //Redis客户端
+	prefix      string        //键前缀
 	expiration  time.Duration //验证码过期时间
 	maxAttempts int           //最大重试次数
 }
 
-func NewRedisCodeCache() *RedisCodeCache {
+// NewSMSCodeCache 根据配置创建验证码缓存，Redis缓存复用已配置的客户端，否则使用进程内共享的内存实现
+func NewSMSCodeCache() SMSCodeCache {
+	expiration := SMSCodeTTL()
+	maxAttempts := config.GetConfig().SMS.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultSMSMaxAttempts
+	}
+
+	if rc, ok := globalCache.(*redisCache); ok {
+		return NewRedisCodeCache(rc.client, rc.prefix, expiration, maxAttempts)
+	}
+	memoryCodeCacheOnce.Do(func() {
+		memoryCodeCache = NewMemoryCodeCache(expiration, maxAttempts)
+	})
+	return memoryCodeCache
+}
+
+// SMSCodeTTL 验证码有效期
+func SMSCodeTTL() time.Duration {
+	ttl := config.GetConfig().SMS.CodeTTL * time.Second
+	if ttl <= 0 {
+		return defaultSMSCodeTTL
+	}
+	return ttl
+}
+
+// SMSCodeLength 验证码长度
+func SMSCodeLength() int {
+	length := config.GetConfig().SMS.CodeLength
+	if length <= 0 {
+		return defaultSMSCodeLength
+	}
+	return length
+}
+
+func NewRedisCodeCache(client *redis.Client, prefix string, expiration time.Duration, maxAttempts int) *RedisCodeCache {
 	return &RedisCodeCache{
-		client:      redis.NewClient(&redis.Options{}),
-		expiration:  10 * time.Second,
-		maxAttempts: 3,
+		client:      client,
+		prefix:      prefix,
+		expiration:  expiration,
+		maxAttempts: maxAttempts,
 	}
 }
 
 func (r *RedisCodeCache) Set(ctx context.Context, biz, phone, code string) error {
-	key := generateSMSKey(biz, phone)
-	attemptsKey := generateAttemptsKey(biz, phone)
+	key := r.prefix + generateSMSKey(biz, phone)
+	attemptsKey := r.prefix + generateAttemptsKey(biz, phone)
 
 	//用管道批量执行命令
 	pipe := r.client.Pipeline()
@@ -41,46 +101,52 @@ func (r *RedisCodeCache) Set(ctx context.Context, biz, phone, code string) error
 	return err
 }
 
-func (r *RedisCodeCache) Verify(ctx context.Context, biz, phone, code string) (bool, error) {
-	key := generateSMSKey(biz, phone)
-	attemptsKey := generateAttemptsKey(biz, phone)
+// 原子校验验证码的Lua脚本：检查尝试次数、递增、比对，成功后删除
+// 返回值：1 验证成功，0 验证码不匹配，-1 验证码已失效，-2 尝试次数过多
+var verifyCodeScript = redis.NewScript(`
+local stored = redis.call('GET', KEYS[1])
+if not stored then
+	return -1
+end
+local attempts = tonumber(redis.call('GET', KEYS[2]) or '0')
+if attempts >= tonumber(ARGV[2]) then
+	return -2
+end
+redis.call('INCR', KEYS[2])
+if redis.call('PTTL', KEYS[2]) < 0 then
+	redis.call('PEXPIRE', KEYS[2], redis.call('PTTL', KEYS[1]))
+end
+if stored ~= ARGV[1] then
+	return 0
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+return 1
+`)
 
-	//获取当前的尝试次数
-	attempts, err := r.client.Get(ctx, attemptsKey).Int()
-	if err != nil && !errors.Is(err, redis.Nil) {
-		return false, err
-	}
-	if attempts > r.maxAttempts {
-		return false, fmt.Errorf("验证次数过多，请重新获取")
-	}
-
-	//增加尝试次数
-	r.client.Incr(ctx, attemptsKey)
+func (r *RedisCodeCache) Verify(ctx context.Context, biz, phone, code string) (bool, error) {
+	key := r.prefix + generateSMSKey(biz, phone)
+	attemptsKey := r.prefix + generateAttemptsKey(biz, phone)
 
-	//获取存储的验证码
-	storeCode, err := r.client.Get(ctx, key).Result()
+	result, err := verifyCodeScript.Run(ctx, r.client, []string{key, attemptsKey}, code, r.maxAttempts).Int()
 	if err != nil {
-		if errors.Is(err, redis.Nil) {
-			return false, fmt.Errorf("验证码失效")
-		}
 		return false, err
 	}
-	//验证码匹配
 
-	if storeCode != code {
-		return false, fmt.Errorf("验证码不匹配，请重新输入")
+	switch result {
+	case 1:
+		return true, nil
+	case -1:
+		return false, ErrCodeExpired
+	case -2:
+		return false, ErrTooManyAttempts
+	default:
+		return false, ErrCodeMismatch
 	}
-	//获取验证码并删除验证码以及尝试次数
-	pipe := r.client.Pipeline()
-	pipe.Del(ctx, key)
-	pipe.Del(ctx, attemptsKey)
-	_, err = pipe.Exec(ctx)
-	return true, err
 }
 
 func (r *RedisCodeCache) Remove(ctx context.Context, biz, phone string) error {
-	key := generateSMSKey(biz, phone)
-	attemptsKey := generateAttemptsKey(biz, phone)
+	key := r.prefix + generateSMSKey(biz, phone)
+	attemptsKey := r.prefix + generateAttemptsKey(biz, phone)
 
 	pipe := r.client.Pipeline()
 	pipe.Del(ctx, key)
@@ -89,12 +155,12 @@ func (r *RedisCodeCache) Remove(ctx context.Context, biz, phone string) error {
 	return err
 }
 
-// 生成缓存键
+// 生成缓存键，手机号统一为E.164格式，避免同一号码的不同写法对应不同验证码
 func generateSMSKey(biz, phone string) string {
-	return fmt.Sprintf("sms:%s:%s", biz, phone)
+	return fmt.Sprintf("sms:%s:%s", biz, phonenumber.Canonical(phone))
 }
 
 // 生成尝试次数键
 func generateAttemptsKey(biz, phone string) string {
-	return fmt.Sprintf("sms:attempts:%s:%s", biz, phone)
+	return fmt.Sprintf("sms:attempts:%s:%s", biz, phonenumber.Canonical(phone))
 }
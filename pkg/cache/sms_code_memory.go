@@ -0,0 +1,86 @@
+// Package cache pkg/cache/sms_code_memory.go
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 内存验证码记录
+type memoryCode struct {
+	code      string
+	attempts  int
+	expiresAt time.Time
+}
+
+// MemoryCodeCache 内存验证码缓存，用于未启用Redis的单实例部署
+type MemoryCodeCache struct {
+	codes       map[string]*memoryCode
+	mu          sync.Mutex
+	expiration  time.Duration //验证码过期时间
+	maxAttempts int           //最大重试次数
+}
+
+// NewMemoryCodeCache 创建内存验证码缓存
+func NewMemoryCodeCache(expiration time.Duration, maxAttempts int) *MemoryCodeCache {
+	return &MemoryCodeCache{
+		codes:       make(map[string]*memoryCode),
+		expiration:  expiration,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Set 保存验证码并重置尝试次数
+func (m *MemoryCodeCache) Set(ctx context.Context, biz, phone, code string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 顺带清理过期的验证码
+	now := time.Now()
+	for key, item := range m.codes {
+		if now.After(item.expiresAt) {
+			delete(m.codes, key)
+		}
+	}
+
+	m.codes[generateSMSKey(biz, phone)] = &memoryCode{
+		code:      code,
+		expiresAt: now.Add(m.expiration),
+	}
+	return nil
+}
+
+// Verify 校验验证码，成功后删除
+func (m *MemoryCodeCache) Verify(ctx context.Context, biz, phone, code string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := generateSMSKey(biz, phone)
+	item, ok := m.codes[key]
+	if !ok || time.Now().After(item.expiresAt) {
+		delete(m.codes, key)
+		return false, ErrCodeExpired
+	}
+
+	if item.attempts >= m.maxAttempts {
+		return false, ErrTooManyAttempts
+	}
+	item.attempts++
+
+	if item.code != code {
+		return false, ErrCodeMismatch
+	}
+
+	delete(m.codes, key)
+	return true, nil
+}
+
+// Remove 删除验证码
+func (m *MemoryCodeCache) Remove(ctx context.Context, biz, phone string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.codes, generateSMSKey(biz, phone))
+	return nil
+}
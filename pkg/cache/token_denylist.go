@@ -0,0 +1,109 @@
+// Package cache pkg/cache/token_denylist.go
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TokenDenylist 令牌黑名单接口
+type TokenDenylist interface {
+	// Revoke 将指定jti加入黑名单，ttl通常为令牌剩余有效期
+	Revoke(ctx context.Context, jti string, ttl time.Duration) error
+
+	// IsRevoked 检查jti是否在黑名单中
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+
+	// RevokeUserBefore 撤销用户在指定时间之前签发的所有令牌
+	RevokeUserBefore(ctx context.Context, userID uint64, before time.Time) error
+
+	// UserRevokedBefore 获取用户令牌的撤销时间点，未设置时返回零值
+	UserRevokedBefore(ctx context.Context, userID uint64) (time.Time, error)
+
+	// RevokeSession 撤销会话下签发的所有访问令牌，ttl通常为访问令牌有效期
+	RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error
+
+	// IsSessionRevoked 检查会话是否已被撤销
+	IsSessionRevoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+// 基于通用缓存的令牌黑名单实现
+type tokenDenylist struct {
+	cache Cache
+	ttl   time.Duration // 用户撤销时间点的保留时间，应不短于最长的令牌有效期
+}
+
+// NewTokenDenylist 创建令牌黑名单
+func NewTokenDenylist(cache Cache, ttl time.Duration) TokenDenylist {
+	return &tokenDenylist{
+		cache: cache,
+		ttl:   ttl,
+	}
+}
+
+// Revoke 撤销令牌
+func (d *tokenDenylist) Revoke(ctx context.Context, jti string, ttl time.Duration) error {
+	// 令牌已自然过期，无需加入黑名单
+	if ttl <= 0 {
+		return nil
+	}
+	return d.cache.Set(ctx, generateDenylistKey(jti), true, ttl)
+}
+
+// IsRevoked 检查令牌是否已撤销
+func (d *tokenDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	if jti == "" {
+		return false, nil
+	}
+	return d.cache.Exists(ctx, generateDenylistKey(jti))
+}
+
+// RevokeUserBefore 撤销用户在指定时间之前签发的令牌，时间点以毫秒精度保存，与令牌签发时间的精度一致
+func (d *tokenDenylist) RevokeUserBefore(ctx context.Context, userID uint64, before time.Time) error {
+	return d.cache.Set(ctx, generateUserRevokeKey(userID), before.UnixMilli(), d.ttl)
+}
+
+// UserRevokedBefore 获取用户令牌撤销时间点
+func (d *tokenDenylist) UserRevokedBefore(ctx context.Context, userID uint64) (time.Time, error) {
+	var ts int64
+	if err := d.cache.Get(ctx, generateUserRevokeKey(userID), &ts); err != nil {
+		if errors.Is(err, ErrCacheMiss) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	return time.UnixMilli(ts), nil
+}
+
+// RevokeSession 撤销会话
+func (d *tokenDenylist) RevokeSession(ctx context.Context, sessionID string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return d.cache.Set(ctx, generateSessionDenylistKey(sessionID), true, ttl)
+}
+
+// IsSessionRevoked 检查会话是否已被撤销
+func (d *tokenDenylist) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	if sessionID == "" {
+		return false, nil
+	}
+	return d.cache.Exists(ctx, generateSessionDenylistKey(sessionID))
+}
+
+// 生成黑名单键
+func generateDenylistKey(jti string) string {
+	return fmt.Sprintf("token:denylist:%s", jti)
+}
+
+// 生成用户撤销时间点键
+func generateUserRevokeKey(userID uint64) string {
+	return fmt.Sprintf("token:revoked_before:%d", userID)
+}
+
+// 生成会话黑名单键
+func generateSessionDenylistKey(sessionID string) string {
+	return fmt.Sprintf("token:denylist:session:%s", sessionID)
+}
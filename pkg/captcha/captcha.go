@@ -0,0 +1,158 @@
+// Package captcha pkg/captcha/captcha.go
+package captcha
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"star-go/pkg/cache"
+	"star-go/pkg/config"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 验证码类型
+const (
+	TypeMath   = "math"   // 算术题
+	TypeDigits = "digits" // 数字字符
+)
+
+// 默认配置
+const (
+	defaultTTL    = 5 * time.Minute
+	defaultLength = 4
+	defaultWidth  = 120
+	defaultHeight = 40
+)
+
+// ErrInvalidCaptcha 图形验证码错误或已失效
+var ErrInvalidCaptcha = errors.New("图形验证码错误或已失效")
+
+// Captcha 生成的图形验证码
+type Captcha struct {
+	ID    string `json:"captcha_id"`
+	Image string `json:"image"` // data:image/png;base64 格式的图片
+}
+
+// Service 图形验证码服务接口
+type Service interface {
+	// Generate 生成验证码，答案保存在缓存中
+	Generate(ctx context.Context) (*Captcha, error)
+
+	// Verify 校验验证码，无论结果如何验证码都只能使用一次
+	Verify(ctx context.Context, id, answer string) error
+}
+
+// 基于通用缓存的验证码服务实现
+type service struct {
+	cache         cache.Cache
+	captchaType   string
+	length        int
+	ttl           time.Duration
+	width, height int
+}
+
+// NewService 根据配置创建图形验证码服务
+func NewService() Service {
+	cfg := config.GetConfig().Captcha
+
+	s := &service{
+		cache:       cache.GetCache(),
+		captchaType: cfg.Type,
+		length:      cfg.Length,
+		ttl:         cfg.TTL * time.Second,
+		width:       cfg.Width,
+		height:      cfg.Height,
+	}
+	if s.captchaType != TypeDigits {
+		s.captchaType = TypeMath
+	}
+	if s.length <= 0 {
+		s.length = defaultLength
+	}
+	if s.ttl <= 0 {
+		s.ttl = defaultTTL
+	}
+	if s.width <= 0 {
+		s.width = defaultWidth
+	}
+	if s.height <= 0 {
+		s.height = defaultHeight
+	}
+	return s
+}
+
+// Generate 生成验证码
+func (s *service) Generate(ctx context.Context) (*Captcha, error) {
+	question, answer := s.challenge()
+
+	image, err := render(question, s.width, s.height)
+	if err != nil {
+		return nil, err
+	}
+
+	id := uuid.NewString()
+	if err := s.cache.Set(ctx, generateCaptchaKey(id), answer, s.ttl); err != nil {
+		return nil, err
+	}
+
+	return &Captcha{ID: id, Image: image}, nil
+}
+
+// Verify 校验验证码
+func (s *service) Verify(ctx context.Context, id, answer string) error {
+	if id == "" || answer == "" {
+		return ErrInvalidCaptcha
+	}
+
+	key := generateCaptchaKey(id)
+	var expected string
+	if err := s.cache.Get(ctx, key, &expected); err != nil {
+		if errors.Is(err, cache.ErrCacheMiss) {
+			return ErrInvalidCaptcha
+		}
+		return err
+	}
+
+	// 一次性使用，防止对同一验证码反复猜测
+	_ = s.cache.Delete(ctx, key)
+
+	if !strings.EqualFold(strings.TrimSpace(answer), expected) {
+		return ErrInvalidCaptcha
+	}
+	return nil
+}
+
+// 生成题目和答案
+func (s *service) challenge() (string, string) {
+	if s.captchaType == TypeDigits {
+		code := make([]byte, s.length)
+		for i := range code {
+			code[i] = byte('0' + rand.IntN(10))
+		}
+		return string(code), string(code)
+	}
+
+	a, b := rand.IntN(9)+1, rand.IntN(9)+1
+	switch rand.IntN(3) {
+	case 0:
+		return fmt.Sprintf("%d+%d=?", a, b), strconv.Itoa(a + b)
+	case 1:
+		// 保证结果不为负数
+		if a < b {
+			a, b = b, a
+		}
+		return fmt.Sprintf("%d-%d=?", a, b), strconv.Itoa(a - b)
+	default:
+		return fmt.Sprintf("%dx%d=?", a, b), strconv.Itoa(a * b)
+	}
+}
+
+// 生成验证码缓存键
+func generateCaptchaKey(id string) string {
+	return fmt.Sprintf("captcha:%s", id)
+}
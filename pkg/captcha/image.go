@@ -0,0 +1,132 @@
+// Package captcha pkg/captcha/image.go
+package captcha
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+)
+
+// 5x7点阵字形，每行低5位从左到右表示像素
+var glyphs = map[rune][7]uint8{
+	'0': {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1': {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3': {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4': {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5': {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6': {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7': {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8': {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9': {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'+': {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	'-': {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'x': {0x00, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x00},
+	'=': {0x00, 0x00, 0x1F, 0x00, 0x1F, 0x00, 0x00},
+	'?': {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+}
+
+// 将文本渲染为带干扰的PNG图片，返回data URL
+func render(text string, width, height int) (string, error) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	// 浅色背景
+	background := color.RGBA{R: uint8(230 + rand.IntN(26)), G: uint8(230 + rand.IntN(26)), B: uint8(230 + rand.IntN(26)), A: 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, background)
+		}
+	}
+
+	// 干扰线
+	for i := 0; i < 4; i++ {
+		drawLine(img, rand.IntN(width), rand.IntN(height), rand.IntN(width), rand.IntN(height), randomColor(120))
+	}
+
+	// 按字符数计算缩放比例，字符之间保留一列间距
+	runes := []rune(text)
+	scale := min(width/(len(runes)*6+2), height/9)
+	scale = max(scale, 1)
+	offsetX := (width - len(runes)*6*scale) / 2
+	for i, r := range runes {
+		glyph, ok := glyphs[r]
+		if !ok {
+			continue
+		}
+		// 每个字符随机颜色和上下抖动
+		fg := randomColor(110)
+		x0 := offsetX + i*6*scale + rand.IntN(scale+1) - scale/2
+		y0 := (height-7*scale)/2 + rand.IntN(scale+1) - scale/2
+		for row := 0; row < 7; row++ {
+			for col := 0; col < 5; col++ {
+				if glyph[row]&(1<<(4-col)) == 0 {
+					continue
+				}
+				fillRect(img, x0+col*scale, y0+row*scale, scale, scale, fg)
+			}
+		}
+	}
+
+	// 噪点
+	for i := 0; i < width*height/20; i++ {
+		img.Set(rand.IntN(width), rand.IntN(height), randomColor(200))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// 随机颜色，limit限制各分量的最大值以控制深浅
+func randomColor(limit int) color.RGBA {
+	return color.RGBA{R: uint8(rand.IntN(limit)), G: uint8(rand.IntN(limit)), B: uint8(rand.IntN(limit)), A: 255}
+}
+
+// 填充矩形
+func fillRect(img *image.RGBA, x, y, w, h int, c color.Color) {
+	for dy := 0; dy < h; dy++ {
+		for dx := 0; dx < w; dx++ {
+			img.Set(x+dx, y+dy, c)
+		}
+	}
+}
+
+// 画线（Bresenham算法）
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
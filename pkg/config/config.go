@@ -17,7 +17,16 @@ type AppConfig struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	Log      LogConfig      `mapstructure:"log"`
-	Cache    CacheConfig    `mapstructure:"cache"` // 缓存配置
+	Cache    CacheConfig    `mapstructure:"cache"`    // 缓存配置
+	Mail     MailConfig     `mapstructure:"mail"`     // 邮件配置
+	SMS      SMSConfig      `mapstructure:"sms"`      // 短信配置
+	Captcha  CaptchaConfig  `mapstructure:"captcha"`  // 图形验证码配置
+	MFA      MFAConfig      `mapstructure:"mfa"`      // 两步验证配置
+	Login    LoginConfig    `mapstructure:"login"`    // 登录保护配置
+	Password PasswordConfig `mapstructure:"password"` // 密码策略配置
+
+	EmailVerify EmailVerifyConfig `mapstructure:"emailVerify"` // 邮箱验证配置
+	OAuth       OAuthConfig       `mapstructure:"oauth"`       // 第三方登录配置
 }
 
 // ServerConfig 服务器配置
@@ -51,11 +60,23 @@ type DatabaseConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret           string        `mapstructure:"secret"`
-	AccessTokenExp   time.Duration `mapstructure:"accessTokenExp"`
-	RefreshTokenExp  time.Duration `mapstructure:"refreshTokenExp"`
-	TokenIssuer      string        `mapstructure:"tokenIssuer"`
-	RefreshTokenSize int           `mapstructure:"refreshTokenSize"`
+	Secret           string         `mapstructure:"secret"`
+	AccessSecret     string         `mapstructure:"accessSecret"`  // 访问令牌密钥，为空时使用secret
+	RefreshSecret    string         `mapstructure:"refreshSecret"` // 刷新令牌密钥，为空时使用secret
+	AccessTokenExp   time.Duration  `mapstructure:"accessTokenExp"`
+	RefreshTokenExp  time.Duration  `mapstructure:"refreshTokenExp"`
+	TokenIssuer      string         `mapstructure:"tokenIssuer"`
+	RefreshTokenSize int            `mapstructure:"refreshTokenSize"`
+	Algorithm        string         `mapstructure:"algorithm"` // 签名算法 (HS256, RS256, ES256, EdDSA)
+	ActiveKid        string         `mapstructure:"activeKid"` // 当前用于签名的密钥ID
+	Keys             []JWTKeyConfig `mapstructure:"keys"`      // 非对称密钥列表，包含轮换中的旧密钥
+}
+
+// JWTKeyConfig JWT非对称密钥配置
+type JWTKeyConfig struct {
+	Kid            string `mapstructure:"kid"`            // 密钥ID
+	PrivateKeyFile string `mapstructure:"privateKeyFile"` // PEM私钥文件路径，仅签名密钥需要
+	PublicKeyFile  string `mapstructure:"publicKeyFile"`  // PEM公钥文件路径，为空时从私钥推导
 }
 
 // CacheConfig 缓存配置
@@ -76,6 +97,137 @@ type CacheConfig struct {
 	EnableLog    bool          `mapstructure:"enableLog"`    // 是否启用日志
 }
 
+// MailConfig 邮件配置
+type MailConfig struct {
+	Driver   string     `mapstructure:"driver"`   // 发送方式 (file, smtp)
+	From     string     `mapstructure:"from"`     // 发件人
+	FilePath string     `mapstructure:"filePath"` // 文件发送器的输出路径
+	SMTP     SMTPConfig `mapstructure:"smtp"`     // SMTP服务器配置
+}
+
+// SMTPConfig SMTP服务器配置
+type SMTPConfig struct {
+	Host        string `mapstructure:"host"`        // 服务器地址
+	Port        int    `mapstructure:"port"`        // 端口
+	Username    string `mapstructure:"username"`    // 用户名
+	Password    string `mapstructure:"password"`    // 密码或授权码
+	ImplicitTLS bool   `mapstructure:"implicitTLS"` // 是否直接使用TLS连接（465端口默认启用），否则尝试STARTTLS
+}
+
+// SMSConfig 短信配置
+type SMSConfig struct {
+	Provider  string            `mapstructure:"provider"`  // 服务商 (console, file, memory, http)
+	SignName  string            `mapstructure:"signName"`  // 短信签名
+	FilePath  string            `mapstructure:"filePath"`  // file服务商的输出路径
+	Templates map[string]string `mapstructure:"templates"` // 按业务类型配置的短信模板，支持{code}和{minutes}占位符
+	HTTP      SMSHTTPConfig     `mapstructure:"http"`      // http服务商配置
+
+	DefaultCountryCode string `mapstructure:"defaultCountryCode"` // 手机号未携带国家码时使用的默认国家码
+
+	CodeTTL     time.Duration `mapstructure:"codeTTL"`     // 验证码有效期（秒）
+	CodeLength  int           `mapstructure:"codeLength"`  // 验证码长度
+	MaxAttempts int           `mapstructure:"maxAttempts"` // 单个验证码最大校验次数
+
+	Limit SMSLimitConfig `mapstructure:"limit"` // 发送频率限制
+}
+
+// SMSLimitConfig 短信发送频率限制配置
+type SMSLimitConfig struct {
+	Cooldown   time.Duration `mapstructure:"cooldown"`   // 同一业务同一手机号的重发间隔（秒）
+	PhoneDaily int           `mapstructure:"phoneDaily"` // 每个手机号每日发送上限
+	IPDaily    int           `mapstructure:"ipDaily"`    // 每个IP每日发送上限
+}
+
+// CaptchaConfig 图形验证码配置
+type CaptchaConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`          // 是否启用，启用后登录、注册和发送短信在失败过多时需要图形验证码
+	Type             string        `mapstructure:"type"`             // 验证码类型 (math, digits)
+	Length           int           `mapstructure:"length"`           // digits类型的字符数
+	TTL              time.Duration `mapstructure:"ttl"`              // 有效期（秒）
+	Width            int           `mapstructure:"width"`            // 图片宽度
+	Height           int           `mapstructure:"height"`           // 图片高度
+	FailureThreshold int           `mapstructure:"failureThreshold"` // 同一IP失败多少次后要求验证码，0表示始终要求
+	FailureWindow    time.Duration `mapstructure:"failureWindow"`    // 失败次数统计窗口（秒）
+}
+
+// PasswordConfig 密码策略与哈希配置
+type PasswordConfig struct {
+	Algorithm        string       `mapstructure:"algorithm"`        // 哈希算法 (argon2id, bcrypt)，旧算法的哈希在登录时自动升级
+	BcryptCost       int          `mapstructure:"bcryptCost"`       // bcrypt成本
+	Argon2           Argon2Config `mapstructure:"argon2"`           // argon2id参数
+	MinLength        int          `mapstructure:"minLength"`        // 最小长度
+	MaxLength        int          `mapstructure:"maxLength"`        // 最大长度
+	RequireUpper     bool         `mapstructure:"requireUpper"`     // 需包含大写字母
+	RequireLower     bool         `mapstructure:"requireLower"`     // 需包含小写字母
+	RequireDigit     bool         `mapstructure:"requireDigit"`     // 需包含数字
+	RequireSymbol    bool         `mapstructure:"requireSymbol"`    // 需包含特殊字符
+	DisallowUsername bool         `mapstructure:"disallowUsername"` // 禁止包含用户名
+	HistorySize      int          `mapstructure:"historySize"`      // 禁止与最近N次使用过的密码相同，0表示不限制
+}
+
+// Argon2Config argon2id参数
+type Argon2Config struct {
+	Memory      uint32 `mapstructure:"memory"`      // 内存开销（KiB）
+	Iterations  uint32 `mapstructure:"iterations"`  // 迭代次数
+	Parallelism uint8  `mapstructure:"parallelism"` // 并行度
+}
+
+// LoginConfig 登录失败保护配置
+type LoginConfig struct {
+	MaxUserFailures int           `mapstructure:"maxUserFailures"` // 同一用户名失败多少次后锁定
+	MaxIPFailures   int           `mapstructure:"maxIPFailures"`   // 同一IP失败多少次后锁定
+	FailureWindow   time.Duration `mapstructure:"failureWindow"`   // 失败次数统计窗口（秒）
+	LockoutDuration time.Duration `mapstructure:"lockoutDuration"` // 锁定时长（秒）
+	DelayAfter      int           `mapstructure:"delayAfter"`      // 连续失败多少次后开始延迟响应
+	DelayStep       time.Duration `mapstructure:"delayStep"`       // 每次额外失败增加的延迟（毫秒）
+	MaxDelay        time.Duration `mapstructure:"maxDelay"`        // 最大延迟（毫秒）
+}
+
+// MFAConfig 两步验证配置
+type MFAConfig struct {
+	Issuer       string        `mapstructure:"issuer"`       // 身份验证器中显示的发行方名称
+	ChallengeTTL time.Duration `mapstructure:"challengeTTL"` // 登录挑战令牌有效期（秒）
+}
+
+// EmailVerifyConfig 邮箱验证配置
+type EmailVerifyConfig struct {
+	Enabled        bool          `mapstructure:"enabled"`        // 是否要求注册用户验证邮箱后才能登录
+	Secret         string        `mapstructure:"secret"`         // 验证链接签名密钥，为空时使用jwt.secret
+	LinkURL        string        `mapstructure:"linkURL"`        // 验证链接地址，令牌以token查询参数附加
+	TTL            time.Duration `mapstructure:"ttl"`            // 验证链接和验证码有效期（秒）
+	ResendCooldown time.Duration `mapstructure:"resendCooldown"` // 重发间隔（秒）
+	DailyLimit     int           `mapstructure:"dailyLimit"`     // 每个邮箱每日发送上限
+}
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	StateTTL  time.Duration        `mapstructure:"stateTTL"`  // 授权请求state有效期（秒）
+	Providers []OIDCProviderConfig `mapstructure:"providers"` // OIDC身份提供方
+}
+
+// OIDCProviderConfig OIDC身份提供方配置
+type OIDCProviderConfig struct {
+	Name         string        `mapstructure:"name"`         // 提供方标识，用于路由和身份关联
+	DisplayName  string        `mapstructure:"displayName"`  // 显示名称
+	Issuer       string        `mapstructure:"issuer"`       // 签发者，未配置端点时通过发现文档获取
+	ClientID     string        `mapstructure:"clientID"`     // 客户端ID
+	ClientSecret string        `mapstructure:"clientSecret"` // 客户端密钥，公共客户端可为空
+	RedirectURL  string        `mapstructure:"redirectURL"`  // 回调地址
+	Scopes       []string      `mapstructure:"scopes"`       // 授权范围，默认openid email profile
+	AuthURL      string        `mapstructure:"authURL"`      // 授权端点（可选）
+	TokenURL     string        `mapstructure:"tokenURL"`     // 令牌端点（可选）
+	JWKSURL      string        `mapstructure:"jwksURL"`      // 公钥端点（可选）
+	AutoRegister bool          `mapstructure:"autoRegister"` // 未关联的外部账户是否自动注册
+	Timeout      time.Duration `mapstructure:"timeout"`      // 请求超时（秒）
+}
+
+// SMSHTTPConfig HTTP短信网关配置
+type SMSHTTPConfig struct {
+	URL     string            `mapstructure:"url"`     // 网关地址
+	Timeout time.Duration     `mapstructure:"timeout"` // 请求超时（秒）
+	Headers map[string]string `mapstructure:"headers"` // 附加请求头
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level         string `mapstructure:"level"`
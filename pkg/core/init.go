@@ -12,6 +12,9 @@ import (
 	"star-go/pkg/config"
 	"star-go/pkg/database"
 	"star-go/pkg/logger"
+	"star-go/pkg/phonenumber"
+	"star-go/pkg/sms"
+	"star-go/pkg/utils"
 	"syscall"
 	"time"
 
@@ -30,6 +33,11 @@ func InitConfig(configPath string) error {
 	return config.InitConfig(configPath)
 }
 
+// InitJWT 初始化JWT签名密钥
+func InitJWT() error {
+	return utils.InitJWTKeys()
+}
+
 // InitLogger 初始化日志系统
 func InitLogger() error {
 	return logger.InitLogger()
@@ -45,6 +53,11 @@ func InitCache() error {
 	return cache.InitCache()
 }
 
+// InitSMS 初始化短信客户端
+func InitSMS() error {
+	return sms.InitSMS()
+}
+
 // InitGin 初始化Gin引擎
 func InitGin() *gin.Engine {
 	// 设置Gin模式
@@ -63,6 +76,11 @@ func InitGin() *gin.Engine {
 	router.Use(logger.GinLogger())
 	router.Use(logger.GinRecovery(true))
 
+	// 注册自定义参数校验规则
+	if err := phonenumber.RegisterValidator(); err != nil {
+		logger.GetLogger().Error("注册手机号校验规则失败", zap.Error(err))
+	}
+
 	return router
 }
 
@@ -134,6 +152,11 @@ func (app *Application) gracefulShutdown() {
 	// 关闭数据库连接
 	database.CloseDatabase()
 
+	// 关闭缓存连接
+	if err := cache.CloseCache(); err != nil {
+		logger.GetLogger().Error("关闭缓存失败", zap.Error(err))
+	}
+
 	// 关闭日志系统
 	logger.CloseLogger()
 
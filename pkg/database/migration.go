@@ -2,29 +2,132 @@
 package database
 
 import (
+	"errors"
 	"star-go/internal/models"
 	"star-go/pkg/logger"
+	"star-go/pkg/phonenumber"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // RunMigrations 执行数据库迁移
 func RunMigrations() error {
 	logger.GetLogger().Info("开始执行数据库迁移...")
 
+	// 手机号改为可空唯一字段，先将历史空字符串转换为NULL
+	if DB.Migrator().HasTable(&models.User{}) && DB.Migrator().HasColumn(&models.User{}, "phone") {
+		if err := DB.Model(&models.User{}).Where("phone = ?", "").Update("phone", nil).Error; err != nil {
+			logger.GetLogger().Error("迁移手机号字段失败", zap.Error(err))
+			return err
+		}
+
+		// 历史手机号统一转换为E.164格式
+		if err := DB.Model(&models.User{}).
+			Where("phone IS NOT NULL AND phone NOT LIKE ?", "+%").
+			Update("phone", gorm.Expr("CONCAT(?, phone)", "+"+phonenumber.DefaultCountryCode())).Error; err != nil {
+			logger.GetLogger().Error("迁移手机号格式失败", zap.Error(err))
+			return err
+		}
+	}
+
+	// 邮箱验证字段上线前注册的用户视为已验证，需在自动迁移前判断字段是否存在
+	backfillEmailVerified := DB.Migrator().HasTable(&models.User{}) && !DB.Migrator().HasColumn(&models.User{}, "email_verified")
+
+	// 角色继承字段上线前创建的内置角色需补充继承关系
+	backfillRoleParents := DB.Migrator().HasTable(&models.Role{}) && !DB.Migrator().HasColumn(&models.Role{}, "parent_id")
+
 	// 自动迁移数据表结构
 	if err := DB.AutoMigrate(
 		&models.User{},
 		&models.Role{},
+		&models.UserSession{},
+		&models.UserRecoveryCode{},
+		&models.UserPasswordHistory{},
+		&models.UserIdentity{},
+		&models.APIKey{},
 	); err != nil {
 		logger.GetLogger().Error("数据库迁移失败", zap.Error(err))
 		return err
 	}
 
+	// 密码字段加宽以容纳参数较大的argon2id哈希
+	if err := widenPasswordColumn(); err != nil {
+		logger.GetLogger().Error("迁移密码字段失败", zap.Error(err))
+		return err
+	}
+
+	// 单角色字段迁移到用户角色关联表
+	if err := migrateUserRoleID(); err != nil {
+		logger.GetLogger().Error("迁移用户角色失败", zap.Error(err))
+		return err
+	}
+
+	if backfillEmailVerified {
+		if err := DB.Model(&models.User{}).Where("1 = 1").Update("email_verified", true).Error; err != nil {
+			logger.GetLogger().Error("迁移邮箱验证状态失败", zap.Error(err))
+			return err
+		}
+	}
+
+	if backfillRoleParents {
+		if err := linkBuiltinRoles(DB); err != nil {
+			logger.GetLogger().Error("迁移角色继承关系失败", zap.Error(err))
+			return err
+		}
+	}
+
 	logger.GetLogger().Info("数据库迁移完成")
 	return nil
 }
 
+// 旧版密码字段长度为100，长度不足时按模型定义修改字段
+func widenPasswordColumn() error {
+	columnTypes, err := DB.Migrator().ColumnTypes(&models.User{})
+	if err != nil {
+		return err
+	}
+	for _, columnType := range columnTypes {
+		if columnType.Name() != "password" {
+			continue
+		}
+		if length, ok := columnType.Length(); ok && length < 255 {
+			logger.GetLogger().Info("加宽用户密码字段...")
+			return DB.Migrator().AlterColumn(&models.User{}, "Password")
+		}
+		return nil
+	}
+	return nil
+}
+
+// 将旧版 star_users.role_id 的数据写入 star_user_roles 后删除该字段
+func migrateUserRoleID() error {
+	if !DB.Migrator().HasColumn(&models.User{}, "role_id") {
+		return nil
+	}
+
+	logger.GetLogger().Info("迁移用户角色到关联表...")
+	err := DB.Exec(`INSERT INTO star_user_roles (user_id, role_id)
+		SELECT u.id, u.role_id FROM star_users u
+		JOIN star_roles r ON r.id = u.role_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM star_user_roles ur WHERE ur.user_id = u.id AND ur.role_id = u.role_id
+		)`).Error
+	if err != nil {
+		return err
+	}
+
+	// 旧的单角色外键约束需先删除
+	for _, constraint := range []string{"fk_star_users_role", "fk_star_roles_users"} {
+		if DB.Migrator().HasConstraint(&models.User{}, constraint) {
+			if err := DB.Migrator().DropConstraint(&models.User{}, constraint); err != nil {
+				return err
+			}
+		}
+	}
+	return DB.Migrator().DropColumn(&models.User{}, "role_id")
+}
+
 // InitAdminUser 初始化管理员账户
 func InitAdminUser() error {
 	// 初始化角色
@@ -55,7 +158,7 @@ func initRoles() error {
 	if count == 0 {
 		logger.GetLogger().Info("创建默认角色...")
 
-		// 创建超级管理员角色
+		// 创建超级管理员角色，继承管理员，自身保留全部权限以免管理员权限调整后受影响
 		superuserRole := &models.Role{
 			Name:        "超级管理员",
 			Code:        models.RoleSuperuser,
@@ -63,23 +166,27 @@ func initRoles() error {
 			Permissions: models.Permissions{models.PermAll},
 		}
 
-		// 创建管理员角色
+		// 创建管理员角色，继承普通用户，只拥有管理类权限，系统备份等高危操作保留给超级管理员
 		adminRole := &models.Role{
 			Name:        "管理员",
 			Code:        models.RoleAdmin,
-			Description: "系统管理员，拥有所有权限",
-			Permissions: models.Permissions{models.PermAll},
+			Description: "系统管理员，拥有用户、内容和角色的管理权限",
+			Permissions: models.Permissions{
+				models.PermUserAll,
+				models.PermContentAll,
+				models.PermRoleManage,
+				models.PermSystemConfig,
+				models.PermSystemLog,
+			},
 		}
 
-		// 创建普通用户角色
+		// 创建普通用户角色，继承访客的查看权限
 		userRole := &models.Role{
 			Name:        "普通用户",
 			Code:        models.RoleUser,
 			Description: "普通用户，拥有基本权限",
 			Permissions: models.Permissions{
-				models.PermUserView,
 				models.PermUserEdit,
-				models.PermContentView,
 				models.PermContentCreate,
 				models.PermContentEdit,
 			},
@@ -96,9 +203,15 @@ func initRoles() error {
 			},
 		}
 
-		// 批量创建角色
-		roles := []*models.Role{superuserRole, adminRole, userRole, guestRole}
-		if err := DB.Create(&roles).Error; err != nil {
+		// 批量创建角色并建立继承关系：访客 ⊂ 普通用户 ⊂ 管理员 ⊂ 超级管理员
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			roles := []*models.Role{superuserRole, adminRole, userRole, guestRole}
+			if err := tx.Create(&roles).Error; err != nil {
+				return err
+			}
+			return linkBuiltinRoles(tx)
+		})
+		if err != nil {
 			logger.GetLogger().Error("创建默认角色失败", zap.Error(err))
 			return err
 		}
@@ -109,13 +222,45 @@ func initRoles() error {
 	return nil
 }
 
+// 按内置角色的层级设置父角色，已设置父角色的角色保持不变
+func linkBuiltinRoles(db *gorm.DB) error {
+	hierarchy := [][2]string{
+		{models.RoleUser, models.RoleGuest},
+		{models.RoleAdmin, models.RoleUser},
+		{models.RoleSuperuser, models.RoleAdmin},
+	}
+	for _, link := range hierarchy {
+		var parent models.Role
+		if err := db.Where("code = ?", link[1]).First(&parent).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+		err := db.Model(&models.Role{}).
+			Where("code = ? AND parent_id IS NULL", link[0]).
+			Update("parent_id", parent.ID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // 初始化管理员账户
 func initAdmin() error {
 	logger.GetLogger().Info("检查并初始化管理员账户...")
 
+	// 查找超级管理员角色
+	var superuserRole models.Role
+	if err := DB.Where("code = ?", models.RoleSuperuser).First(&superuserRole).Error; err != nil {
+		logger.GetLogger().Error("查询超级管理员角色失败", zap.Error(err))
+		return err
+	}
+
 	// 检查是否已存在管理员账户
 	var count int64
-	if err := DB.Model(&models.User{}).Where("role_id = ?", 1).Count(&count).Error; err != nil {
+	if err := DB.Table("star_user_roles").Where("role_id = ?", superuserRole.ID).Count(&count).Error; err != nil {
 		logger.GetLogger().Error("查询管理员账户失败", zap.Error(err))
 		return err
 	}
@@ -129,8 +274,10 @@ func initAdmin() error {
 			Username: "admin",
 			Email:    "admin@example.com",
 			Nickname: "系统管理员",
-			RoleID:   1, // 管理员角色ID为1
+			Roles:    []*models.Role{&superuserRole},
 			Status:   models.StatusActive,
+
+			EmailVerified: true,
 		}
 
 		// 设置默认密码
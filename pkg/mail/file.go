@@ -0,0 +1,53 @@
+// Package mail pkg/mail/file.go
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileMailer 将邮件写入本地文件，用于开发和测试环境
+type FileMailer struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+// NewFileMailer 创建文件邮件发送器
+func NewFileMailer(path, from string) *FileMailer {
+	if path == "" {
+		path = "./logs/mail.log"
+	}
+	return &FileMailer{
+		path: path,
+		from: from,
+	}
+}
+
+// Send 追加写入邮件到文件
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 确保目录存在
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return fmt.Errorf("创建邮件目录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(m.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开邮件文件失败: %w", err)
+	}
+	defer file.Close()
+
+	content := fmt.Sprintf("===== %s =====\r\n%s\r\n%s\r\n\r\n",
+		time.Now().Format("2006-01-02 15:04:05"), formatHeader(m.from, msg), msg.Body)
+	if _, err := file.WriteString(content); err != nil {
+		return fmt.Errorf("写入邮件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package mail pkg/mail/mail.go
+package mail
+
+import (
+	"context"
+	"fmt"
+	"star-go/pkg/config"
+	"star-go/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Message 邮件内容
+type Message struct {
+	To      string // 收件人
+	Subject string // 主题
+	Body    string // 正文（纯文本）
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	// Send 发送邮件
+	Send(ctx context.Context, msg *Message) error
+}
+
+// NewMailer 根据配置创建邮件发送器
+func NewMailer() Mailer {
+	cfg := config.GetConfig().Mail
+
+	switch cfg.Driver {
+	case "file", "":
+		return NewFileMailer(cfg.FilePath, cfg.From)
+	case "smtp":
+		return NewSMTPMailer(cfg.SMTP, cfg.From)
+	default:
+		logger.GetLogger().Warn("未知的邮件发送方式，使用文件发送器", zap.String("driver", cfg.Driver))
+		return NewFileMailer(cfg.FilePath, cfg.From)
+	}
+}
+
+// 格式化发件人
+func formatFrom(from string) string {
+	if from == "" {
+		return "star-go <no-reply@star-go.local>"
+	}
+	return from
+}
+
+// 构造邮件头
+func formatHeader(from string, msg *Message) string {
+	return fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n", formatFrom(from), msg.To, msg.Subject)
+}
@@ -2,8 +2,9 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"star-go/internal/models"
 	"star-go/internal/services"
 	"star-go/pkg/utils"
 	"strings"
@@ -13,6 +14,10 @@ import (
 
 // JWTAuth 认证中间件
 func JWTAuth() gin.HandlerFunc {
+	// 创建认证服务，用于检查令牌是否已被撤销
+	authService := services.NewAuthService()
+	apiKeyService := services.NewAPIKeyService()
+
 	return func(c *gin.Context) {
 		// 从请求头获取Authorization
 		authHeader := c.GetHeader("Authorization")
@@ -25,12 +30,16 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
-		// 检查Bearer前缀
+		// 检查Bearer或ApiKey前缀
 		parts := strings.SplitN(authHeader, " ", 2)
+		if len(parts) == 2 && parts[0] == "ApiKey" {
+			authenticateAPIKey(c, apiKeyService, parts[1])
+			return
+		}
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
-				"message": "认证格式错误，应为 'Bearer {token}'",
+				"message": "认证格式错误，应为 'Bearer {token}' 或 'ApiKey {key}'",
 			})
 			c.Abort()
 			return
@@ -48,54 +57,145 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 检查令牌是否已被撤销（退出登录或退出所有设备）
+		revoked, err := authService.IsTokenRevoked(c, claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "检查令牌状态失败: " + err.Error(),
+			})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "认证令牌已失效，请重新登录",
+			})
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存储到上下文中
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("claims", claims)
 
 		c.Next()
 	}
 }
 
-// RoleAuth 角色授权中间件
-func RoleAuth(roleCode string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 获取用户ID
-		userID, exists := c.Get("userID")
-		if !exists {
+// 使用API密钥认证，密钥的授权范围保存在上下文中供权限中间件检查
+func authenticateAPIKey(c *gin.Context, apiKeyService services.IAPIKeyService, rawKey string) {
+	key, user, err := apiKeyService.Authenticate(c, rawKey, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKey) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
-				"message": "未认证用户",
+				"message": err.Error(),
+			})
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"code":    401,
+				"message": "API密钥认证失败: " + err.Error(),
 			})
-			c.Abort()
-			return
 		}
+		c.Abort()
+		return
+	}
 
-		// 创建用户服务
-		userService := services.NewUserService()
+	// 将用户信息存储到上下文中
+	c.Set("userID", user.ID)
+	c.Set("username", user.Username)
+	c.Set("apiKey", key)
 
-		// 获取用户信息
-		user, err := userService.GetUserByID(userID.(uint64))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "获取用户信息失败: " + err.Error(),
+	c.Next()
+}
+
+// SessionOnly 仅允许登录令牌访问的中间件，API密钥不能用于修改密码、两步验证等账户安全操作
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := c.Get("apiKey"); isAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{
+				"code":    403,
+				"message": "该操作不支持使用API密钥，请使用登录令牌",
 			})
 			c.Abort()
 			return
 		}
 
-		if user == nil {
+		c.Next()
+	}
+}
+
+// 获取当前请求用户的有效角色和权限，同一请求只解析一次，结果保存在上下文中供后续的权限中间件复用
+func requestPermissions(c *gin.Context, permissionService services.IPermissionService) (*services.UserPermissions, bool) {
+	if resolved, exists := c.Get("permissions"); exists {
+		return resolved.(*services.UserPermissions), true
+	}
+
+	// 获取用户ID
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"code":    401,
+			"message": "未认证用户",
+		})
+		c.Abort()
+		return nil, false
+	}
+
+	// 解析用户的有效角色和权限
+	resolved, err := permissionService.Resolve(c, userID.(uint64))
+	if err != nil {
+		if errors.Is(err, services.ErrPermissionUserNotFound) {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"code":    401,
 				"message": "用户不存在",
 			})
-			c.Abort()
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "获取用户权限失败: " + err.Error(),
+			})
+		}
+		c.Abort()
+		return nil, false
+	}
+
+	c.Set("permissions", resolved)
+	return resolved, true
+}
+
+// 检查当前请求是否拥有指定权限，API密钥请求还需在密钥的授权范围内
+func requestHasPermission(c *gin.Context, resolved *services.UserPermissions, permission string) bool {
+	if key, isAPIKey := c.Get("apiKey"); isAPIKey && !key.(*models.APIKey).Scopes.HasPermission(permission) {
+		return false
+	}
+	return resolved.HasPermission(permission)
+}
+
+// 检查当前请求的用户是否拥有指定角色（任一已分配角色匹配即可），API密钥只有授予全部权限时才视为拥有所属用户的角色
+func requestHasRole(c *gin.Context, resolved *services.UserPermissions, roleCode string) bool {
+	if key, isAPIKey := c.Get("apiKey"); isAPIKey && !key.(*models.APIKey).Scopes.HasPermission(models.PermAll) {
+		return false
+	}
+	return resolved.HasRole(roleCode)
+}
+
+// RoleAuth 角色授权中间件
+func RoleAuth(roleCode string) gin.HandlerFunc {
+	permissionService := services.NewPermissionService()
+
+	return func(c *gin.Context) {
+		// 获取用户的有效角色和权限
+		resolved, ok := requestPermissions(c, permissionService)
+		if !ok {
 			return
 		}
 
 		// 检查用户角色
-		fmt.Println("User Role:", user.Role.Code, "Required Role:", roleCode)
-		if user.Role == nil || user.Role.Code != roleCode {
+		if !requestHasRole(c, resolved, roleCode) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code":    403,
 				"message": "权限不足，需要 " + roleCode + " 角色",
@@ -110,33 +210,17 @@ func RoleAuth(roleCode string) gin.HandlerFunc {
 
 // PermissionAuth 权限授权中间件
 func PermissionAuth(permission string) gin.HandlerFunc {
+	permissionService := services.NewPermissionService()
+
 	return func(c *gin.Context) {
-		// 获取用户ID
-		userID, exists := c.Get("userID")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "未认证用户",
-			})
-			c.Abort()
+		// 获取用户的有效角色和权限
+		resolved, ok := requestPermissions(c, permissionService)
+		if !ok {
 			return
 		}
 
-		// 创建用户服务
-		userService := services.NewUserService()
-
 		// 检查用户是否有指定权限
-		hasPermission, err := userService.HasPermission(userID.(uint64), permission)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "检查权限失败: " + err.Error(),
-			})
-			c.Abort()
-			return
-		}
-
-		if !hasPermission {
+		if !requestHasPermission(c, resolved, permission) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code":    403,
 				"message": "权限不足，需要 " + permission + " 权限",
@@ -151,43 +235,17 @@ func PermissionAuth(permission string) gin.HandlerFunc {
 
 // RoleAndPermissionAuth 同时需要角色和权限的中间件
 func RoleAndPermissionAuth(roleCode string, permission string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 获取用户ID
-		userID, exists := c.Get("userID")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "未认证用户",
-			})
-			c.Abort()
-			return
-		}
-
-		// 创建用户服务
-		userService := services.NewUserService()
-
-		// 获取用户信息
-		user, err := userService.GetUserByID(userID.(uint64))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "获取用户信息失败: " + err.Error(),
-			})
-			c.Abort()
-			return
-		}
+	permissionService := services.NewPermissionService()
 
-		if user == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "用户不存在",
-			})
-			c.Abort()
+	return func(c *gin.Context) {
+		// 获取用户的有效角色和权限
+		resolved, ok := requestPermissions(c, permissionService)
+		if !ok {
 			return
 		}
 
 		// 检查用户角色
-		if user.Role == nil || user.Role.Code != roleCode {
+		if !requestHasRole(c, resolved, roleCode) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code":    403,
 				"message": "权限不足，需要 " + roleCode + " 角色",
@@ -197,17 +255,7 @@ func RoleAndPermissionAuth(roleCode string, permission string) gin.HandlerFunc {
 		}
 
 		// 检查用户是否有指定权限
-		hasPermission, err := userService.HasPermission(userID.(uint64), permission)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "检查权限失败: " + err.Error(),
-			})
-			c.Abort()
-			return
-		}
-
-		if !hasPermission {
+		if !requestHasPermission(c, resolved, permission) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code":    403,
 				"message": "权限不足，需要 " + permission + " 权限",
@@ -222,57 +270,17 @@ func RoleAndPermissionAuth(roleCode string, permission string) gin.HandlerFunc {
 
 // RoleOrPermissionAuth 需要角色或权限的中间件（满足其一即可）
 func RoleOrPermissionAuth(roleCode string, permission string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 获取用户ID
-		userID, exists := c.Get("userID")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "未认证用户",
-			})
-			c.Abort()
-			return
-		}
-
-		// 创建用户服务
-		userService := services.NewUserService()
+	permissionService := services.NewPermissionService()
 
-		// 获取用户信息
-		user, err := userService.GetUserByID(userID.(uint64))
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "获取用户信息失败: " + err.Error(),
-			})
-			c.Abort()
-			return
-		}
-
-		if user == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "用户不存在",
-			})
-			c.Abort()
-			return
-		}
-
-		// 检查用户角色
-		hasRole := user.Role != nil && user.Role.Code == roleCode
-
-		// 检查用户是否有指定权限
-		hasPermission, err := userService.HasPermission(userID.(uint64), permission)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"code":    500,
-				"message": "检查权限失败: " + err.Error(),
-			})
-			c.Abort()
+	return func(c *gin.Context) {
+		// 获取用户的有效角色和权限
+		resolved, ok := requestPermissions(c, permissionService)
+		if !ok {
 			return
 		}
 
 		// 如果既没有所需角色也没有所需权限，则拒绝访问
-		if !hasRole && !hasPermission {
+		if !requestHasRole(c, resolved, roleCode) && !requestHasPermission(c, resolved, permission) {
 			c.JSON(http.StatusForbidden, gin.H{
 				"code":    403,
 				"message": "权限不足，需要 " + roleCode + " 角色或 " + permission + " 权限",
@@ -287,28 +295,18 @@ func RoleOrPermissionAuth(roleCode string, permission string) gin.HandlerFunc {
 
 // AnyPermissionAuth 需要多个权限中的任意一个的中间件
 func AnyPermissionAuth(permissions ...string) gin.HandlerFunc {
+	permissionService := services.NewPermissionService()
+
 	return func(c *gin.Context) {
-		// 获取用户ID
-		userID, exists := c.Get("userID")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "未认证用户",
-			})
-			c.Abort()
+		// 获取用户的有效角色和权限
+		resolved, ok := requestPermissions(c, permissionService)
+		if !ok {
 			return
 		}
 
-		// 创建用户服务
-		userService := services.NewUserService()
-
 		// 检查用户是否有指定权限中的任意一个
 		for _, permission := range permissions {
-			hasPermission, err := userService.HasPermission(userID.(uint64), permission)
-			if err != nil {
-				continue // 忽略错误，继续检查其他权限
-			}
-			if hasPermission {
+			if requestHasPermission(c, resolved, permission) {
 				// 有任意一个权限即可通过
 				c.Next()
 				return
@@ -329,33 +327,18 @@ func AnyPermissionAuth(permissions ...string) gin.HandlerFunc {
 
 // AllPermissionsAuth 需要所有指定权限的中间件
 func AllPermissionsAuth(permissions ...string) gin.HandlerFunc {
+	permissionService := services.NewPermissionService()
+
 	return func(c *gin.Context) {
-		// 获取用户ID
-		userID, exists := c.Get("userID")
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    401,
-				"message": "未认证用户",
-			})
-			c.Abort()
+		// 获取用户的有效角色和权限
+		resolved, ok := requestPermissions(c, permissionService)
+		if !ok {
 			return
 		}
 
-		// 创建用户服务
-		userService := services.NewUserService()
-
 		// 检查用户是否拥有所有指定权限
 		for _, permission := range permissions {
-			hasPermission, err := userService.HasPermission(userID.(uint64), permission)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"code":    500,
-					"message": "检查权限失败: " + err.Error(),
-				})
-				c.Abort()
-				return
-			}
-			if !hasPermission {
+			if !requestHasPermission(c, resolved, permission) {
 				// 缺少任意一个权限都拒绝访问
 				c.JSON(http.StatusForbidden, gin.H{
 					"code":    403,
@@ -0,0 +1,78 @@
+// Package middleware pkg/middleware/auth_test.go
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"star-go/pkg/cache"
+	"star-go/pkg/config"
+	"star-go/pkg/logger"
+	"star-go/pkg/utils"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 初始化测试所需的配置、日志和内存缓存
+func setupAuthTest(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger.Log = zap.NewNop()
+
+	cfg := config.GetConfig()
+	cfg.JWT = config.JWTConfig{
+		Algorithm:       utils.AlgHS256,
+		Secret:          "test-secret",
+		AccessTokenExp:  15,
+		RefreshTokenExp: 60,
+	}
+	cfg.Cache.Type = "memory"
+	if err := cache.InitCache(); err != nil {
+		t.Fatalf("初始化缓存失败: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/protected", JWTAuth(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetUint64("userID")})
+	})
+	return router
+}
+
+func TestJWTAuthRejectsRefreshToken(t *testing.T) {
+	router := setupAuthTest(t)
+
+	accessToken, err := utils.GenerateAccessToken(1, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refreshToken, err := utils.GenerateRefreshToken(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{"访问令牌", "Bearer " + accessToken, http.StatusOK},
+		{"刷新令牌", "Bearer " + refreshToken, http.StatusUnauthorized},
+		{"缺少认证头", "", http.StatusUnauthorized},
+		{"错误的认证格式", "Token " + accessToken, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Package middleware pkg/middleware/captcha.go
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"star-go/pkg/cache"
+	"star-go/pkg/captcha"
+	"star-go/pkg/config"
+	"star-go/pkg/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 图形验证码请求头
+const (
+	CaptchaIDHeader     = "X-Captcha-Id"
+	CaptchaAnswerHeader = "X-Captcha-Answer"
+)
+
+// 失败次数统计窗口默认值
+const defaultCaptchaFailureWindow = 15 * time.Minute
+
+// CaptchaGuard 图形验证码中间件
+// 同一IP在统计窗口内失败次数达到阈值后，请求需在请求头中携带有效的图形验证码；未启用时直接放行
+func CaptchaGuard() gin.HandlerFunc {
+	cfg := config.GetConfig().Captcha
+	if !cfg.Enabled {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	captchaService := captcha.NewService()
+	store := cache.GetCache()
+	window := cfg.FailureWindow * time.Second
+	if window <= 0 {
+		window = defaultCaptchaFailureWindow
+	}
+
+	return func(c *gin.Context) {
+		key := generateCaptchaFailureKey(c.ClientIP())
+
+		// 获取该IP的失败次数
+		var failures int
+		if err := store.Get(c, key, &failures); err != nil && !errors.Is(err, cache.ErrCacheMiss) {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"code":    500,
+				"message": "检查图形验证码状态失败: " + err.Error(),
+			})
+			c.Abort()
+			return
+		}
+
+		// 达到阈值后要求图形验证码
+		if failures >= cfg.FailureThreshold {
+			err := captchaService.Verify(c, c.GetHeader(CaptchaIDHeader), c.GetHeader(CaptchaAnswerHeader))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"code":    utils.CAPTCHA_INVALID,
+					"message": "请输入正确的图形验证码",
+					"data": gin.H{
+						"captcha_required": true,
+					},
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+
+		// 请求失败时累计失败次数
+		if c.Writer.Status() >= http.StatusBadRequest {
+			_, _ = store.Incr(c, key, window)
+		}
+	}
+}
+
+// 生成IP失败次数键
+func generateCaptchaFailureKey(ip string) string {
+	return fmt.Sprintf("captcha:failures:%s", ip)
+}
@@ -0,0 +1,353 @@
+// Package oidc pkg/oidc/oidc.go
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"star-go/pkg/config"
+	"star-go/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 默认配置
+const (
+	defaultTimeout     = 10 * time.Second
+	jwksRefreshMinWait = time.Minute // 遇到未知kid时重新拉取公钥的最小间隔
+	maxResponseSize    = 1 << 20
+)
+
+// 允许的id_token签名算法，不接受none和对称算法
+var allowedAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "ES256", "ES384", "EdDSA"}
+
+// ErrInvalidIDToken id_token校验失败
+var ErrInvalidIDToken = errors.New("id_token无效")
+
+// Token 令牌端点返回的令牌
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Claims id_token中的声明
+type Claims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// 发现文档中使用的端点
+type endpoints struct {
+	Issuer   string `json:"issuer"`
+	AuthURL  string `json:"authorization_endpoint"`
+	TokenURL string `json:"token_endpoint"`
+	JWKSURL  string `json:"jwks_uri"`
+}
+
+// Provider OIDC身份提供方客户端
+type Provider struct {
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+
+	mu            sync.Mutex
+	endpoints     *endpoints
+	keys          map[string]crypto.PublicKey
+	keysFetchedAt time.Time
+}
+
+// NewProvider 根据配置创建身份提供方客户端，端点在首次使用时发现
+func NewProvider(cfg config.OIDCProviderConfig) *Provider {
+	timeout := cfg.Timeout * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name 提供方标识
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// DisplayName 提供方显示名称
+func (p *Provider) DisplayName() string {
+	if p.cfg.DisplayName != "" {
+		return p.cfg.DisplayName
+	}
+	return p.cfg.Name
+}
+
+// AutoRegister 未关联的外部账户是否自动注册
+func (p *Provider) AutoRegister() bool {
+	return p.cfg.AutoRegister
+}
+
+// AuthCodeURL 生成授权地址，使用PKCE（S256）防止授权码被截获后冒用
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	ep, err := p.getEndpoints(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", p.cfg.RedirectURL)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", CodeChallenge(codeVerifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(ep.AuthURL, "?") {
+		separator = "&"
+	}
+	return ep.AuthURL + separator + params.Encode(), nil
+}
+
+// Exchange 使用授权码和PKCE校验码换取令牌
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*Token, error) {
+	ep, err := p.getEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("client_id", p.cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("创建令牌请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	var token Token
+	if err := p.doJSON(req, &token); err != nil {
+		return nil, fmt.Errorf("换取令牌失败: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("令牌响应中缺少id_token")
+	}
+	return &token, nil
+}
+
+// VerifyIDToken 校验id_token的签名、签发者、受众、有效期和nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	ep, err := p.getEndpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	},
+		jwt.WithValidMethods(allowedAlgorithms),
+		jwt.WithIssuer(ep.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: 缺少sub", ErrInvalidIDToken)
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce不匹配", ErrInvalidIDToken)
+	}
+	// 存在多个受众时授权方必须是当前客户端
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp不匹配", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// 获取端点，配置中缺少的端点通过发现文档补全
+func (p *Provider) getEndpoints(ctx context.Context) (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+
+	ep := &endpoints{
+		Issuer:   p.cfg.Issuer,
+		AuthURL:  p.cfg.AuthURL,
+		TokenURL: p.cfg.TokenURL,
+		JWKSURL:  p.cfg.JWKSURL,
+	}
+	if ep.AuthURL == "" || ep.TokenURL == "" || ep.JWKSURL == "" {
+		discoveryURL := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("创建发现请求失败: %w", err)
+		}
+
+		var discovered endpoints
+		if err := p.doJSON(req, &discovered); err != nil {
+			return nil, fmt.Errorf("获取OIDC发现文档失败: %w", err)
+		}
+		// 发现文档中的签发者必须与配置一致，防止被其他签发者冒充
+		if discovered.Issuer != p.cfg.Issuer {
+			return nil, fmt.Errorf("发现文档的签发者不匹配: %s", discovered.Issuer)
+		}
+
+		if ep.AuthURL == "" {
+			ep.AuthURL = discovered.AuthURL
+		}
+		if ep.TokenURL == "" {
+			ep.TokenURL = discovered.TokenURL
+		}
+		if ep.JWKSURL == "" {
+			ep.JWKSURL = discovered.JWKSURL
+		}
+	}
+	if ep.AuthURL == "" || ep.TokenURL == "" || ep.JWKSURL == "" {
+		return nil, errors.New("OIDC端点配置不完整")
+	}
+
+	p.endpoints = ep
+	return ep, nil
+}
+
+// 根据kid获取公钥，未知kid时重新拉取公钥集合以支持提供方轮换密钥
+func (p *Provider) publicKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keysFetchedAt) < jwksRefreshMinWait {
+		return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+	}
+
+	if err := p.fetchKeys(ctx); err != nil {
+		return nil, err
+	}
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未知的签名密钥: %s", kid)
+}
+
+// 查找公钥，令牌未指定kid且只有一个公钥时使用该公钥
+func (p *Provider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// 拉取公钥集合，调用方需持有锁
+func (p *Provider) fetchKeys(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoints.JWKSURL, nil)
+	if err != nil {
+		return fmt.Errorf("创建公钥请求失败: %w", err)
+	}
+
+	var set utils.JWKSet
+	if err := p.doJSON(req, &set); err != nil {
+		return fmt.Errorf("获取公钥集合失败: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			// 跳过不支持的密钥类型
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+	return nil
+}
+
+// 发送请求并解析JSON响应
+func (p *Provider) doJSON(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("响应状态码 %d: %s", resp.StatusCode, truncate(string(body), 256))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// GenerateRandom 生成URL安全的随机字符串，用于state、nonce和PKCE校验码
+func GenerateRandom() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("生成随机数失败: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
+
+// CodeChallenge 计算PKCE的S256校验值
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// 截断过长的错误信息
+func truncate(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	return s[:size] + "..."
+}
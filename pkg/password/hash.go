@@ -0,0 +1,158 @@
+// Package password pkg/password/hash.go
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"star-go/pkg/config"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 支持的哈希算法
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// argon2id默认参数（RFC 9106 推荐的低内存配置）
+const (
+	defaultArgon2Memory      = 64 * 1024 // KiB
+	defaultArgon2Iterations  = 3
+	defaultArgon2Parallelism = 2
+	argon2SaltLength         = 16
+	argon2KeyLength          = 32
+)
+
+// ErrInvalidHash 无法识别的密码哈希格式
+var ErrInvalidHash = errors.New("无法识别的密码哈希格式")
+
+// argon2id参数
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// Hash 使用配置的算法计算密码哈希
+func Hash(plain string) (string, error) {
+	if algorithm() == AlgorithmBcrypt {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(plain), bcryptCost())
+		if err != nil {
+			return "", err
+		}
+		return string(hashed), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := currentArgon2Params()
+	key := argon2.IDKey([]byte(plain), salt, p.iterations, p.memory, p.parallelism, argon2KeyLength)
+
+	// PHC字符串格式
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify 校验密码是否与哈希匹配，自动识别哈希算法
+func Verify(plain, hashed string) bool {
+	if strings.HasPrefix(hashed, "$argon2id$") {
+		p, salt, key, err := decodeArgon2(hashed)
+		if err != nil {
+			return false
+		}
+		actual := argon2.IDKey([]byte(plain), salt, p.iterations, p.memory, p.parallelism, uint32(len(key)))
+		return subtle.ConstantTimeCompare(actual, key) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(plain)) == nil
+}
+
+// NeedsRehash 哈希使用的算法或参数与当前配置不一致时返回true，应在登录成功后重新计算
+func NeedsRehash(hashed string) bool {
+	if strings.HasPrefix(hashed, "$argon2id$") {
+		if algorithm() != AlgorithmArgon2id {
+			return true
+		}
+		p, _, _, err := decodeArgon2(hashed)
+		return err != nil || p != currentArgon2Params()
+	}
+
+	if algorithm() != AlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return err != nil || cost != bcryptCost()
+}
+
+// 解析PHC格式的argon2id哈希
+func decodeArgon2(hashed string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	return p, salt, key, nil
+}
+
+// 配置的哈希算法，默认argon2id
+func algorithm() string {
+	if config.GetConfig().Password.Algorithm == AlgorithmBcrypt {
+		return AlgorithmBcrypt
+	}
+	return AlgorithmArgon2id
+}
+
+// 配置的bcrypt成本
+func bcryptCost() int {
+	cost := config.GetConfig().Password.BcryptCost
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+// 配置的argon2id参数
+func currentArgon2Params() argon2Params {
+	cfg := config.GetConfig().Password.Argon2
+	p := argon2Params{
+		memory:      cfg.Memory,
+		iterations:  cfg.Iterations,
+		parallelism: cfg.Parallelism,
+	}
+	if p.memory == 0 {
+		p.memory = defaultArgon2Memory
+	}
+	if p.iterations == 0 {
+		p.iterations = defaultArgon2Iterations
+	}
+	if p.parallelism == 0 {
+		p.parallelism = defaultArgon2Parallelism
+	}
+	return p
+}
@@ -0,0 +1,75 @@
+// Package password pkg/password/policy.go
+package password
+
+import (
+	"errors"
+	"fmt"
+	"star-go/pkg/config"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 密码策略默认值
+const (
+	defaultMinLength = 8
+	defaultMaxLength = 64
+)
+
+// ErrPasswordReused 新密码与近期使用过的密码相同
+var ErrPasswordReused = errors.New("新密码不能与最近使用过的密码相同")
+
+// Validate 按配置的密码策略校验密码强度，username用于禁止密码包含用户名
+func Validate(plain, username string) error {
+	cfg := config.GetConfig().Password
+
+	minLength, maxLength := cfg.MinLength, cfg.MaxLength
+	if minLength <= 0 {
+		minLength = defaultMinLength
+	}
+	if maxLength <= 0 {
+		maxLength = defaultMaxLength
+	}
+
+	length := utf8.RuneCountInString(plain)
+	if length < minLength || length > maxLength {
+		return fmt.Errorf("密码长度需为%d到%d个字符", minLength, maxLength)
+	}
+
+	// 统计字符类别
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		default:
+			hasSymbol = true
+		}
+	}
+	if cfg.RequireUpper && !hasUpper {
+		return errors.New("密码需包含大写字母")
+	}
+	if cfg.RequireLower && !hasLower {
+		return errors.New("密码需包含小写字母")
+	}
+	if cfg.RequireDigit && !hasDigit {
+		return errors.New("密码需包含数字")
+	}
+	if cfg.RequireSymbol && !hasSymbol {
+		return errors.New("密码需包含特殊字符")
+	}
+
+	if cfg.DisallowUsername && username != "" && strings.Contains(strings.ToLower(plain), strings.ToLower(username)) {
+		return errors.New("密码不能包含用户名")
+	}
+	return nil
+}
+
+// HistorySize 需要检查的历史密码数量，0表示不检查
+func HistorySize() int {
+	return max(config.GetConfig().Password.HistorySize, 0)
+}
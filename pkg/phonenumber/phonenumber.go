@@ -0,0 +1,155 @@
+// Package phonenumber pkg/phonenumber/phonenumber.go
+package phonenumber
+
+import (
+	"errors"
+	"regexp"
+	"star-go/pkg/config"
+	"strings"
+)
+
+// 未配置时使用的默认国家码（中国大陆）
+const defaultCountryCode = "86"
+
+// ErrInvalidPhone 手机号格式无效
+var ErrInvalidPhone = errors.New("无效的手机号")
+
+// 一位和两位的国家码，其余国家码均为三位（国家码具有前缀唯一性）
+var shortCountryCodes = map[string]bool{
+	"1": true, "7": true,
+	"20": true, "27": true, "30": true, "31": true, "32": true, "33": true, "34": true, "36": true,
+	"39": true, "40": true, "41": true, "43": true, "44": true, "45": true, "46": true, "47": true,
+	"48": true, "49": true, "51": true, "52": true, "53": true, "54": true, "55": true, "56": true,
+	"57": true, "58": true, "60": true, "61": true, "62": true, "63": true, "64": true, "65": true,
+	"66": true, "81": true, "82": true, "84": true, "86": true, "90": true, "91": true, "92": true,
+	"93": true, "94": true, "95": true, "98": true,
+}
+
+// 部分国家和地区的号码规则，未列出的仅校验E.164长度
+var nationalPatterns = map[string]*regexp.Regexp{
+	"86":  regexp.MustCompile(`^1[3-9]\d{9}$`),          // 中国大陆手机号
+	"852": regexp.MustCompile(`^[4-9]\d{7}$`),           // 中国香港
+	"853": regexp.MustCompile(`^6\d{7}$`),               // 中国澳门
+	"886": regexp.MustCompile(`^9\d{8}$`),               // 中国台湾
+	"1":   regexp.MustCompile(`^[2-9]\d{2}[2-9]\d{6}$`), // 北美
+}
+
+// 号码中允许出现的分隔符
+var separatorReplacer = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// Number 解析后的电话号码
+type Number struct {
+	CountryCode    string // 国家码，不含+
+	NationalNumber string // 国内号码
+}
+
+// E164 返回E.164规范格式，如 +8613800138000
+func (n Number) E164() string {
+	return "+" + n.CountryCode + n.NationalNumber
+}
+
+// Parse 解析电话号码，未携带国家码时使用defaultCC
+// 支持 +8613800138000、008613800138000、13800138000 以及带空格、横线的写法
+func Parse(raw, defaultCC string) (Number, error) {
+	digits := separatorReplacer.Replace(strings.TrimSpace(raw))
+
+	var international bool
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits, international = digits[1:], true
+	case strings.HasPrefix(digits, "00"):
+		digits, international = digits[2:], true
+	}
+	if digits == "" || !isDigits(digits) {
+		return Number{}, ErrInvalidPhone
+	}
+
+	var number Number
+	if international {
+		number.CountryCode = splitCountryCode(digits)
+		number.NationalNumber = digits[len(number.CountryCode):]
+	} else {
+		number.CountryCode = defaultCC
+		// 去掉国内长途前缀0
+		number.NationalNumber = strings.TrimPrefix(digits, "0")
+	}
+
+	if err := validate(number); err != nil {
+		return Number{}, err
+	}
+	return number, nil
+}
+
+// Normalize 将电话号码转换为E.164规范格式，未携带国家码时使用配置的默认国家码
+func Normalize(raw string) (string, error) {
+	number, err := Parse(raw, DefaultCountryCode())
+	if err != nil {
+		return "", err
+	}
+	return number.E164(), nil
+}
+
+// Canonical 返回E.164规范格式，无法解析时原样返回，用于生成缓存键等不需要报错的场景
+func Canonical(raw string) string {
+	normalized, err := Normalize(raw)
+	if err != nil {
+		return raw
+	}
+	return normalized
+}
+
+// IsValid 检查电话号码是否有效
+func IsValid(raw string) bool {
+	_, err := Normalize(raw)
+	return err == nil
+}
+
+// DefaultCountryCode 获取配置的默认国家码
+func DefaultCountryCode() string {
+	cc := strings.TrimPrefix(config.GetConfig().SMS.DefaultCountryCode, "+")
+	if cc == "" {
+		return defaultCountryCode
+	}
+	return cc
+}
+
+// 从国际号码中拆分国家码
+func splitCountryCode(digits string) string {
+	for size := 1; size <= 2 && size < len(digits); size++ {
+		if shortCountryCodes[digits[:size]] {
+			return digits[:size]
+		}
+	}
+	if len(digits) < 3 {
+		return digits
+	}
+	return digits[:3]
+}
+
+// 校验号码长度和已知地区的号码规则
+func validate(number Number) error {
+	if number.CountryCode == "" || number.CountryCode[0] == '0' || !isDigits(number.CountryCode) {
+		return ErrInvalidPhone
+	}
+
+	// E.164规定号码总长度不超过15位
+	total := len(number.CountryCode) + len(number.NationalNumber)
+	if len(number.NationalNumber) < 4 || total > 15 {
+		return ErrInvalidPhone
+	}
+
+	if pattern, ok := nationalPatterns[number.CountryCode]; ok && !pattern.MatchString(number.NationalNumber) {
+		return ErrInvalidPhone
+	}
+	return nil
+}
+
+// 是否全部为数字
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,21 @@
+// Package phonenumber pkg/phonenumber/validator.go
+package phonenumber
+
+import (
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// ValidatorTag 参数校验标签，用法: binding:"required,phone"
+const ValidatorTag = "phone"
+
+// RegisterValidator 向gin的参数校验器注册手机号校验规则
+func RegisterValidator() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+	return v.RegisterValidation(ValidatorTag, func(fl validator.FieldLevel) bool {
+		return IsValid(fl.Field().String())
+	})
+}
@@ -0,0 +1,68 @@
+// Package sms pkg/sms/http.go
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"star-go/pkg/config"
+	"time"
+)
+
+// 基于HTTP的短信客户端，将短信以JSON形式POST到服务商网关或本地模拟服务
+type httpClient struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// 创建HTTP短信客户端
+func newHTTPClient(cfg config.SMSHTTPConfig) (*httpClient, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("未配置短信网关地址")
+	}
+
+	timeout := cfg.Timeout * time.Second
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &httpClient{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		client:  &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Send 发送短信
+func (c *httpClient) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("序列化短信失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建短信请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求短信网关失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("短信网关返回错误: %d %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
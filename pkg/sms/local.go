@@ -0,0 +1,126 @@
+// Package sms pkg/sms/local.go
+package sms
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"star-go/pkg/logger"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 控制台短信客户端，将短信输出到日志
+type consoleClient struct{}
+
+// 创建控制台短信客户端
+func newConsoleClient() *consoleClient {
+	return &consoleClient{}
+}
+
+// Send 输出短信到日志
+func (c *consoleClient) Send(ctx context.Context, msg *Message) error {
+	logger.GetLogger().Info("发送短信",
+		zap.String("phone", msg.Phone),
+		zap.String("biz", msg.Biz),
+		zap.String("content", msg.Content))
+	return nil
+}
+
+// 文件短信客户端，将短信追加写入本地文件
+type fileClient struct {
+	path string
+	mu   sync.Mutex
+}
+
+// 创建文件短信客户端
+func newFileClient(path string) *fileClient {
+	if path == "" {
+		path = "./logs/sms.log"
+	}
+	return &fileClient{path: path}
+}
+
+// Send 追加写入短信到文件
+func (c *fileClient) Send(ctx context.Context, msg *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// 确保目录存在
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("创建短信目录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(c.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开短信文件失败: %w", err)
+	}
+	defer file.Close()
+
+	line := fmt.Sprintf("[%s] %s %s %s\n", time.Now().Format("2006-01-02 15:04:05"), msg.Phone, msg.Biz, msg.Content)
+	if _, err := file.WriteString(line); err != nil {
+		return fmt.Errorf("写入短信失败: %w", err)
+	}
+	return nil
+}
+
+// InboxMessage 收件箱中的短信
+type InboxMessage struct {
+	Message
+	SentAt time.Time `json:"sent_at"`
+}
+
+// 内存短信客户端，短信保存在进程内收件箱，供测试读取
+type memoryClient struct {
+	messages []InboxMessage
+	mu       sync.RWMutex
+}
+
+// 收件箱最多保留的短信数量
+const inboxCapacity = 200
+
+// 创建内存短信客户端
+func newMemoryClient() *memoryClient {
+	return &memoryClient{}
+}
+
+// Send 保存短信到收件箱
+func (c *memoryClient) Send(ctx context.Context, msg *Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.messages = append(c.messages, InboxMessage{Message: *msg, SentAt: time.Now()})
+	if len(c.messages) > inboxCapacity {
+		c.messages = c.messages[len(c.messages)-inboxCapacity:]
+	}
+	return nil
+}
+
+// Messages 获取指定手机号的短信，手机号为空时返回全部，按发送时间倒序
+func (c *memoryClient) Messages(phone string) []InboxMessage {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]InboxMessage, 0)
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if phone == "" || c.messages[i].Phone == phone {
+			result = append(result, c.messages[i])
+		}
+	}
+	return result
+}
+
+// Inbox 进程内收件箱
+type Inbox interface {
+	// Messages 获取指定手机号的短信，手机号为空时返回全部
+	Messages(phone string) []InboxMessage
+}
+
+// GetInbox 获取进程内收件箱，仅当服务商为memory时可用
+func GetInbox() (Inbox, bool) {
+	inbox, ok := globalClient.(*memoryClient)
+	return inbox, ok
+}
@@ -0,0 +1,88 @@
+// Package sms pkg/sms/sms.go
+package sms
+
+import (
+	"context"
+	"fmt"
+	"star-go/pkg/config"
+	"star-go/pkg/logger"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Message 短信内容
+type Message struct {
+	Phone   string `json:"phone"`   // 接收手机号
+	Biz     string `json:"biz"`     // 业务类型
+	Content string `json:"content"` // 短信正文
+}
+
+// Client 短信发送客户端接口，不同服务商提供各自实现
+type Client interface {
+	// Send 发送短信
+	Send(ctx context.Context, msg *Message) error
+}
+
+// 全局短信客户端
+var globalClient Client
+
+// InitSMS 根据配置初始化短信客户端
+func InitSMS() error {
+	cfg := config.GetConfig().SMS
+
+	var err error
+	switch cfg.Provider {
+	case "console", "":
+		globalClient = newConsoleClient()
+	case "file":
+		globalClient = newFileClient(cfg.FilePath)
+	case "memory":
+		globalClient = newMemoryClient()
+	case "http":
+		globalClient, err = newHTTPClient(cfg.HTTP)
+	default:
+		logger.GetLogger().Warn("未知的短信服务商，使用控制台输出", zap.String("provider", cfg.Provider))
+		globalClient = newConsoleClient()
+	}
+
+	if err != nil {
+		return fmt.Errorf("初始化短信客户端失败: %w", err)
+	}
+
+	logger.GetLogger().Info("短信客户端初始化成功", zap.String("provider", cfg.Provider))
+	return nil
+}
+
+// GetClient 获取短信客户端
+func GetClient() Client {
+	return globalClient
+}
+
+// 默认短信模板
+var defaultTemplates = map[string]string{
+	"default": "您的验证码是: {code}，请勿泄露给他人。",
+}
+
+// RenderTemplate 按业务类型渲染短信模板，未配置的业务使用默认模板
+func RenderTemplate(biz string, params map[string]string) string {
+	templates := config.GetConfig().SMS.Templates
+
+	tpl, ok := templates[biz]
+	if !ok {
+		tpl, ok = templates["default"]
+	}
+	if !ok {
+		tpl = defaultTemplates["default"]
+	}
+
+	// 签名前置
+	if signName := config.GetConfig().SMS.SignName; signName != "" {
+		tpl = "【" + signName + "】" + tpl
+	}
+
+	for key, value := range params {
+		tpl = strings.ReplaceAll(tpl, "{"+key+"}", value)
+	}
+	return tpl
+}
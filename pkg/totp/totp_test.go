@@ -0,0 +1,66 @@
+// Package totp pkg/totp/totp_test.go
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录B的SHA1测试密钥 "12345678901234567890"
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// RFC 6238 附录B的SHA1测试向量，RFC给出8位验证码，6位验证码为其末6位
+var rfcVectors = []struct {
+	unix int64
+	step uint64
+	code string
+}{
+	{59, 0x1, "287082"},                 // 94287082
+	{1111111109, 0x23523EC, "081804"},   // 07081804
+	{1111111111, 0x23523ED, "050471"},   // 14050471
+	{1234567890, 0x273EF07, "005924"},   // 89005924
+	{2000000000, 0x3F940AA, "279037"},   // 69279037
+	{20000000000, 0x27BC86AA, "353130"}, // 65353130
+}
+
+func TestGenerateCodeRFC6238(t *testing.T) {
+	for _, tt := range rfcVectors {
+		at := time.Unix(tt.unix, 0).UTC()
+		if step := Step(at); step != tt.step {
+			t.Errorf("Step(%d) = %#x, want %#x", tt.unix, step, tt.step)
+		}
+		code, err := GenerateCode(rfcSecret, tt.step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("GenerateCode(T=%d) = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestValidateRFC6238(t *testing.T) {
+	for _, tt := range rfcVectors {
+		at := time.Unix(tt.unix, 0)
+		step, ok := Validate(rfcSecret, tt.code, at)
+		if !ok || step != tt.step {
+			t.Errorf("Validate(T=%d) = (%#x, %v), want (%#x, true)", tt.unix, step, ok, tt.step)
+		}
+
+		// 允许前后各一个周期的时钟误差，超出范围则拒绝
+		if _, ok := Validate(rfcSecret, tt.code, at.Add(Period)); !ok {
+			t.Errorf("Validate(T=%d+30s) 应在容差范围内", tt.unix)
+		}
+		if _, ok := Validate(rfcSecret, tt.code, at.Add(3*Period)); ok {
+			t.Errorf("Validate(T=%d+90s) 应超出容差范围", tt.unix)
+		}
+	}
+
+	if _, ok := Validate(rfcSecret, "28708", time.Unix(59, 0)); ok {
+		t.Error("位数不足的验证码应被拒绝")
+	}
+	if _, ok := Validate("not base32!", "287082", time.Unix(59, 0)); ok {
+		t.Error("无效密钥应被拒绝")
+	}
+}
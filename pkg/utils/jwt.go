@@ -7,36 +7,74 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// 令牌类型
+const (
+	TokenTypeAccess  = "access"  // 访问令牌
+	TokenTypeRefresh = "refresh" // 刷新令牌
 )
 
 // JWTClaims 自定义JWT Claims
 type JWTClaims struct {
-	UserID   uint64 `json:"user_id"`
-	Username string `json:"username"`
-	Role     string `json:"role"`
+	UserID    uint64 `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	TokenType string `json:"token_type"`    // 令牌类型，解析时强制校验
+	SessionID string `json:"sid,omitempty"` // 所属登录会话ID
 	jwt.RegisteredClaims
 }
 
 // GenerateAccessToken 生成访问令牌
-func GenerateAccessToken(userID uint64) (string, error) {
-	cfg := config.GetConfig().JWT
+func GenerateAccessToken(userID uint64, sessionID string) (string, error) {
+	// 设置JWT声明 - jti用于注销时将令牌加入黑名单，sid用于撤销整个会话
+	claims := JWTClaims{
+		UserID:    userID,
+		SessionID: sessionID,
+	}
+	return generateToken(&claims, TokenTypeAccess, AccessTokenTTL())
+}
 
-	// 设置JWT声明
+// GenerateRefreshToken 生成刷新令牌
+func GenerateRefreshToken(userID uint64) (string, error) {
+	// 设置JWT声明 - 刷新令牌只包含最小必要信息，使用唯一ID保证每次签发的令牌不同
 	claims := JWTClaims{
 		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(cfg.AccessTokenExp) * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    cfg.TokenIssuer,
-		},
+	}
+	return generateToken(&claims, TokenTypeRefresh, RefreshTokenTTL())
+}
+
+// 填充通用声明并签名令牌
+func generateToken(claims *JWTClaims, tokenType string, ttl time.Duration) (string, error) {
+	cfg := config.GetConfig().JWT
+	now := time.Now()
+
+	claims.TokenType = tokenType
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+		Issuer:    cfg.TokenIssuer,
 	}
 
-	// 创建JWT令牌
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// 对称算法使用令牌类型对应的密钥签名
+	if isSymmetric(cfg.Algorithm) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(signingSecret(tokenType))
+	}
+
+	// 非对称算法使用当前签名密钥，并在头部写入kid便于轮换
+	key, err := activeSigningKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
 
 	// 签名令牌
-	tokenString, err := token.SignedString([]byte(cfg.Secret))
+	tokenString, err := token.SignedString(key.privateKey)
 	if err != nil {
 		return "", err
 	}
@@ -44,66 +82,97 @@ func GenerateAccessToken(userID uint64) (string, error) {
 	return tokenString, nil
 }
 
-// GenerateRefreshToken 生成刷新令牌
-func GenerateRefreshToken(userID uint64) (string, error) {
+// 获取令牌类型对应的签名密钥，未单独配置时使用通用密钥
+func signingSecret(tokenType string) []byte {
 	cfg := config.GetConfig().JWT
-
-	// 设置JWT声明 - 刷新令牌只包含最小必要信息
-	claims := JWTClaims{
-		UserID: userID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(cfg.RefreshTokenExp) * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    cfg.TokenIssuer,
-		},
+	switch tokenType {
+	case TokenTypeAccess:
+		if cfg.AccessSecret != "" {
+			return []byte(cfg.AccessSecret)
+		}
+	case TokenTypeRefresh:
+		if cfg.RefreshSecret != "" {
+			return []byte(cfg.RefreshSecret)
+		}
 	}
+	return []byte(cfg.Secret)
+}
 
-	// 创建JWT令牌
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	// 签名令牌
-	tokenString, err := token.SignedString([]byte(cfg.Secret))
-	if err != nil {
-		return "", err
+// 获取当前配置的签名算法
+func signingAlgorithm() string {
+	alg := config.GetConfig().JWT.Algorithm
+	if isSymmetric(alg) {
+		return AlgHS256
 	}
+	return alg
+}
 
-	return tokenString, nil
+// AccessTokenTTL 访问令牌有效期
+func AccessTokenTTL() time.Duration {
+	return time.Duration(config.GetConfig().JWT.AccessTokenExp) * time.Minute
+}
+
+// RefreshTokenTTL 刷新令牌有效期
+func RefreshTokenTTL() time.Duration {
+	return time.Duration(config.GetConfig().JWT.RefreshTokenExp) * time.Minute
 }
 
 // ParseAccessToken 解析访问令牌
 func ParseAccessToken(tokenString string) (*JWTClaims, error) {
-	return parseToken(tokenString)
+	return parseToken(tokenString, TokenTypeAccess)
 }
 
 // ParseRefreshToken 解析刷新令牌
 func ParseRefreshToken(tokenString string) (*JWTClaims, error) {
-	return parseToken(tokenString)
+	return parseToken(tokenString, TokenTypeRefresh)
 }
 
-// 解析JWT令牌
-func parseToken(tokenString string) (*JWTClaims, error) {
+// 解析JWT令牌，并校验令牌类型
+func parseToken(tokenString string, tokenType string) (*JWTClaims, error) {
 	cfg := config.GetConfig().JWT
 
 	// 解析令牌
+	options := []jwt.ParserOption{jwt.WithValidMethods([]string{signingAlgorithm()})}
+	if cfg.TokenIssuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.TokenIssuer))
+	}
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// 验证签名算法
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		// 对称算法
+		if isSymmetric(cfg.Algorithm) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, errors.New("无效的签名方法")
+			}
+			return signingSecret(tokenType), nil
+		}
+
+		// 非对称算法根据kid选择验签公钥
+		kid, _ := token.Header["kid"].(string)
+		key, err := verificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("无效的签名方法")
 		}
-		return []byte(cfg.Secret), nil
-	})
+		return key.publicKey, nil
+	}, options...)
 
 	if err != nil {
 		return nil, err
 	}
 
 	// 验证令牌有效性并转换为自定义Claims
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("无效的令牌")
+	}
+
+	// 验证令牌类型，防止刷新令牌被当作访问令牌使用
+	if claims.TokenType != tokenType {
+		return nil, errors.New("令牌类型不匹配")
 	}
 
-	return nil, errors.New("无效的令牌")
+	return claims, nil
 }
 
 // ValidateToken 验证令牌是否有效
@@ -0,0 +1,324 @@
+// Package utils pkg/utils/jwt_keys.go
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"star-go/pkg/config"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 支持的签名算法
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgEdDSA = "EdDSA"
+)
+
+// JWK JSON Web Key 公钥表示
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA模数
+	E   string `json:"e,omitempty"`   // RSA指数
+	Crv string `json:"crv,omitempty"` // 曲线名称
+	X   string `json:"x,omitempty"`   // EC/OKP X坐标
+	Y   string `json:"y,omitempty"`   // EC Y坐标
+}
+
+// JWKSet JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// 非对称签名密钥
+type signingKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey crypto.PrivateKey // 仅当前签名密钥需要
+	publicKey  crypto.PublicKey
+}
+
+// 非对称密钥集合
+type keySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+var (
+	globalKeySet *keySet
+	keySetMu     sync.RWMutex
+)
+
+// InitJWTKeys 加载JWT签名密钥，对称算法（HS256）无需加载；
+// 同时将签发时间等时间声明设为毫秒精度，使"退出所有设备"后在同一秒内重新登录签发的令牌不被误判为已撤销
+func InitJWTKeys() error {
+	jwt.TimePrecision = time.Millisecond
+
+	cfg := config.GetConfig().JWT
+	if isSymmetric(cfg.Algorithm) {
+		return nil
+	}
+
+	method := jwt.GetSigningMethod(cfg.Algorithm)
+	if method == nil || !isSupportedAsymmetric(cfg.Algorithm) {
+		return fmt.Errorf("不支持的JWT签名算法: %s", cfg.Algorithm)
+	}
+	if len(cfg.Keys) == 0 {
+		return errors.New("未配置JWT签名密钥")
+	}
+
+	set := &keySet{keys: make(map[string]*signingKey)}
+	for _, keyCfg := range cfg.Keys {
+		key, err := loadSigningKey(cfg.Algorithm, keyCfg)
+		if err != nil {
+			return fmt.Errorf("加载JWT密钥 %s 失败: %w", keyCfg.Kid, err)
+		}
+		key.method = method
+		if _, exists := set.keys[key.kid]; exists {
+			return fmt.Errorf("JWT密钥kid重复: %s", key.kid)
+		}
+		set.keys[key.kid] = key
+	}
+
+	// 确定当前签名密钥
+	active, ok := set.keys[cfg.ActiveKid]
+	if !ok {
+		return fmt.Errorf("当前签名密钥不存在: %s", cfg.ActiveKid)
+	}
+	if active.privateKey == nil {
+		return fmt.Errorf("当前签名密钥缺少私钥: %s", cfg.ActiveKid)
+	}
+	set.active = active
+
+	keySetMu.Lock()
+	globalKeySet = set
+	keySetMu.Unlock()
+	return nil
+}
+
+// GetJWKS 获取用于验签的公钥集合
+func GetJWKS() JWKSet {
+	jwks := JWKSet{Keys: []JWK{}}
+
+	keySetMu.RLock()
+	set := globalKeySet
+	keySetMu.RUnlock()
+	if set == nil || isSymmetric(config.GetConfig().JWT.Algorithm) {
+		return jwks
+	}
+
+	for _, key := range set.keys {
+		jwk, err := toJWK(key)
+		if err != nil {
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	return jwks
+}
+
+// 是否为对称签名算法
+func isSymmetric(alg string) bool {
+	return alg == "" || alg == AlgHS256
+}
+
+// 是否为支持的非对称算法
+func isSupportedAsymmetric(alg string) bool {
+	switch alg {
+	case AlgRS256, AlgES256, AlgEdDSA:
+		return true
+	default:
+		return false
+	}
+}
+
+// 获取当前非对称签名密钥
+func activeSigningKey() (*signingKey, error) {
+	keySetMu.RLock()
+	defer keySetMu.RUnlock()
+	if globalKeySet == nil {
+		return nil, errors.New("JWT密钥未初始化")
+	}
+	return globalKeySet.active, nil
+}
+
+// 根据kid查找验签密钥，缺少kid时使用当前签名密钥
+func verificationKey(kid string) (*signingKey, error) {
+	keySetMu.RLock()
+	defer keySetMu.RUnlock()
+	if globalKeySet == nil {
+		return nil, errors.New("JWT密钥未初始化")
+	}
+	if kid == "" {
+		return globalKeySet.active, nil
+	}
+	key, ok := globalKeySet.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("未知的密钥ID: %s", kid)
+	}
+	return key, nil
+}
+
+// 从PEM文件加载密钥
+func loadSigningKey(alg string, keyCfg config.JWTKeyConfig) (*signingKey, error) {
+	if keyCfg.Kid == "" {
+		return nil, errors.New("kid不能为空")
+	}
+	key := &signingKey{kid: keyCfg.Kid}
+
+	// 加载私钥（可选，仅用于签名）
+	if keyCfg.PrivateKeyFile != "" {
+		data, err := os.ReadFile(keyCfg.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		switch alg {
+		case AlgRS256:
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.privateKey, key.publicKey = privateKey, &privateKey.PublicKey
+		case AlgES256:
+			privateKey, err := jwt.ParseECPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			if privateKey.Curve != elliptic.P256() {
+				return nil, errors.New("ES256仅支持P-256曲线")
+			}
+			key.privateKey, key.publicKey = privateKey, &privateKey.PublicKey
+		case AlgEdDSA:
+			privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, err
+			}
+			key.privateKey = privateKey
+			key.publicKey = privateKey.(ed25519.PrivateKey).Public()
+		}
+	}
+
+	// 加载公钥，未配置时从私钥推导
+	if keyCfg.PublicKeyFile != "" {
+		data, err := os.ReadFile(keyCfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		switch alg {
+		case AlgRS256:
+			key.publicKey, err = jwt.ParseRSAPublicKeyFromPEM(data)
+		case AlgES256:
+			key.publicKey, err = jwt.ParseECPublicKeyFromPEM(data)
+		case AlgEdDSA:
+			key.publicKey, err = jwt.ParseEdPublicKeyFromPEM(data)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if key.publicKey == nil {
+		return nil, errors.New("未配置公钥或私钥文件")
+	}
+	return key, nil
+}
+
+// 将公钥转换为JWK
+func toJWK(key *signingKey) (JWK, error) {
+	jwk := JWK{
+		Kid: key.kid,
+		Use: "sig",
+		Alg: key.method.Alg(),
+	}
+
+	switch publicKey := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = publicKey.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return JWK{}, errors.New("不支持的公钥类型")
+	}
+	return jwk, nil
+}
+
+// PublicKey 将JWK还原为公钥，用于验证外部签发的令牌（如OIDC的id_token）
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("无效的RSA模数: %w", err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("无效的RSA指数: %w", err)
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, errors.New("无效的RSA指数")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("无效的EC坐标: %w", err)
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("无效的EC坐标: %w", err)
+		}
+		publicKey := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.New("EC公钥不在曲线上")
+		}
+		return publicKey, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("不支持的曲线: %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("无效的Ed25519公钥")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型: %s", k.Kty)
+	}
+}
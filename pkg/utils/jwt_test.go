@@ -0,0 +1,152 @@
+// Package utils pkg/utils/jwt_test.go
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"star-go/pkg/config"
+	"testing"
+	"time"
+)
+
+// 使用指定的JWT配置执行测试，结束后恢复原配置
+func withJWTConfig(t *testing.T, cfg config.JWTConfig) {
+	t.Helper()
+	original := config.GetConfig().JWT
+	t.Cleanup(func() {
+		config.GetConfig().JWT = original
+		keySetMu.Lock()
+		globalKeySet = nil
+		keySetMu.Unlock()
+	})
+
+	cfg.AccessTokenExp = 15
+	cfg.RefreshTokenExp = 60
+	config.GetConfig().JWT = cfg
+	if err := InitJWTKeys(); err != nil {
+		t.Fatalf("初始化JWT密钥失败: %v", err)
+	}
+}
+
+// 生成ES256私钥文件
+func writeECKey(t *testing.T) string {
+	t.Helper()
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "es256.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseTokenRejectsWrongType(t *testing.T) {
+	configs := map[string]func(t *testing.T) config.JWTConfig{
+		"HS256共用密钥": func(t *testing.T) config.JWTConfig {
+			return config.JWTConfig{Algorithm: AlgHS256, Secret: "shared-secret", TokenIssuer: "star-go"}
+		},
+		"HS256独立密钥": func(t *testing.T) config.JWTConfig {
+			return config.JWTConfig{
+				Algorithm:     AlgHS256,
+				Secret:        "shared-secret",
+				AccessSecret:  "access-secret",
+				RefreshSecret: "refresh-secret",
+			}
+		},
+		"ES256": func(t *testing.T) config.JWTConfig {
+			return config.JWTConfig{
+				Algorithm: AlgES256,
+				ActiveKid: "k1",
+				Keys:      []config.JWTKeyConfig{{Kid: "k1", PrivateKeyFile: writeECKey(t)}},
+			}
+		},
+	}
+
+	for name, build := range configs {
+		t.Run(name, func(t *testing.T) {
+			withJWTConfig(t, build(t))
+
+			accessToken, err := GenerateAccessToken(1, "session")
+			if err != nil {
+				t.Fatalf("生成访问令牌失败: %v", err)
+			}
+			refreshToken, err := GenerateRefreshToken(1)
+			if err != nil {
+				t.Fatalf("生成刷新令牌失败: %v", err)
+			}
+
+			tests := []struct {
+				name    string
+				parse   func(string) (*JWTClaims, error)
+				token   string
+				wantErr bool
+			}{
+				{"访问令牌作为访问令牌", ParseAccessToken, accessToken, false},
+				{"刷新令牌作为刷新令牌", ParseRefreshToken, refreshToken, false},
+				{"刷新令牌作为访问令牌", ParseAccessToken, refreshToken, true},
+				{"访问令牌作为刷新令牌", ParseRefreshToken, accessToken, true},
+				{"篡改的令牌", ParseAccessToken, accessToken[:len(accessToken)-2] + "xx", true},
+				{"非令牌字符串", ParseAccessToken, "not-a-token", true},
+			}
+			for _, tt := range tests {
+				claims, err := tt.parse(tt.token)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+					continue
+				}
+				if err == nil && claims.UserID != 1 {
+					t.Errorf("%s: UserID = %d, want 1", tt.name, claims.UserID)
+				}
+			}
+
+			if ValidateToken(refreshToken) {
+				t.Error("ValidateToken 不应接受刷新令牌")
+			}
+		})
+	}
+}
+
+func TestParseTokenRejectsWrongIssuer(t *testing.T) {
+	withJWTConfig(t, config.JWTConfig{Algorithm: AlgHS256, Secret: "shared-secret", TokenIssuer: "other"})
+	token, err := GenerateAccessToken(1, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.GetConfig().JWT.TokenIssuer = "star-go"
+	if _, err := ParseAccessToken(token); err == nil {
+		t.Error("签发者不匹配的令牌应被拒绝")
+	}
+}
+
+func TestTokenIssuedAtMillisecondPrecision(t *testing.T) {
+	withJWTConfig(t, config.JWTConfig{Algorithm: AlgHS256, Secret: "shared-secret"})
+
+	before := time.Now().Truncate(time.Millisecond)
+	token, err := GenerateAccessToken(1, "session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+	claims, err := ParseAccessToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 签发时间不能被截断到秒；解析时经float64转换可能少1毫秒
+	issuedAt := claims.IssuedAt.Time
+	if issuedAt.Before(before.Add(-time.Millisecond)) || issuedAt.After(after) {
+		t.Errorf("IssuedAt = %v, want [%v, %v]", issuedAt, before.Add(-time.Millisecond), after)
+	}
+}
@@ -15,6 +15,14 @@ const (
 	UNAUTHORIZED   = 401
 	FORBIDDEN      = 403
 	NOT_FOUND      = 404
+
+	TOO_MANY_REQUESTS = 429
+
+	// 验证码相关
+	CODE_EXPIRED           = 4001
+	CODE_MISMATCH          = 4002
+	CODE_TOO_MANY_ATTEMPTS = 4003
+	CAPTCHA_INVALID        = 4004
 )
 
 // MsgFlags 响应消息
@@ -25,6 +33,13 @@ var MsgFlags = map[int]string{
 	UNAUTHORIZED:   "未授权访问",
 	FORBIDDEN:      "禁止访问",
 	NOT_FOUND:      "资源不存在",
+
+	TOO_MANY_REQUESTS: "请求过于频繁",
+
+	CODE_EXPIRED:           "验证码已失效",
+	CODE_MISMATCH:          "验证码错误",
+	CODE_TOO_MANY_ATTEMPTS: "验证次数过多",
+	CAPTCHA_INVALID:        "图形验证码错误",
 }
 
 // 获取响应消息
@@ -83,7 +98,7 @@ func FailWithMessage(c *gin.Context, code int, message string, data interface{})
 // 根据业务码获取HTTP状态码
 func getHttpStatusByCode(code int) int {
 	switch code {
-	case INVALID_PARAMS:
+	case INVALID_PARAMS, CODE_EXPIRED, CODE_MISMATCH, CAPTCHA_INVALID:
 		return http.StatusBadRequest
 	case UNAUTHORIZED:
 		return http.StatusUnauthorized
@@ -91,6 +106,8 @@ func getHttpStatusByCode(code int) int {
 		return http.StatusForbidden
 	case NOT_FOUND:
 		return http.StatusNotFound
+	case TOO_MANY_REQUESTS, CODE_TOO_MANY_ATTEMPTS:
+		return http.StatusTooManyRequests
 	case ERROR:
 		return http.StatusInternalServerError
 	default: